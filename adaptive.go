@@ -0,0 +1,179 @@
+// Copyright 2026 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stan
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// AdaptiveInflightConfig configures the AdaptiveMaxInflight option.
+// Zero values are replaced with defaults.
+type AdaptiveInflightConfig struct {
+	// MinInflight is the lowest MaxInflight. Defaults to 1.
+	MinInflight int
+	// MaxInflight is the highest MaxInflight. Defaults to DefaultMaxInflight.
+	MaxInflight int
+	// Increase is added to MaxInflight when there is enough headroom.
+	// Defaults to 1.
+	Increase int
+	// Interval is the period at which the handler latency is evaluated.
+	// Defaults to the subscription's AckWait.
+	Interval time.Duration
+	// Threshold is the fraction of AckWait above which the estimated time
+	// for the handler to process MaxInflight messages causes MaxInflight
+	// to be halved. MaxInflight is increased when the estimate is below
+	// half of the threshold. Defaults to 0.5.
+	Threshold float64
+	// DecisionCB, if not nil, is invoked when MaxInflight is changed.
+	DecisionCB InflightDecisionHandler
+}
+
+// InflightDecision describes a change of MaxInflight made by the
+// AdaptiveMaxInflight option.
+type InflightDecision struct {
+	// Old is the MaxInflight before the change.
+	Old int
+	// New is the MaxInflight after the change.
+	New int
+	// AvgLatency is the average handler latency during the last interval.
+	AvgLatency time.Duration
+	// Estimated is the estimated time to process Old messages.
+	Estimated time.Duration
+	// AckWait is the subscription's AckWait.
+	AckWait time.Duration
+	// Err is set if the subscription could not be updated.
+	Err error
+}
+
+// InflightDecisionHandler is used to be notified of the decisions made
+// by the AdaptiveMaxInflight option.
+type InflightDecisionHandler func(sub Subscription, decision InflightDecision)
+
+// AdaptiveMaxInflight is an Option to adjust the MaxInflight of a durable
+// subscription based on the latency of its handler. At each interval, the
+// time to process MaxInflight messages is estimated from the average handler
+// latency. If the estimate leaves too little headroom before AckWait, so
+// that messages risk being redelivered, MaxInflight is halved. If there is
+// plenty of headroom and the subscription received at least MaxInflight
+// messages during the interval, MaxInflight is increased by a fixed amount.
+// The subscription is updated with Subscription.Update.
+func AdaptiveMaxInflight(config AdaptiveInflightConfig) SubscriptionOption {
+	return func(o *SubscriptionOptions) error {
+		if config.MinInflight < 0 || config.MaxInflight < 0 || config.Increase < 0 ||
+			config.Interval < 0 || config.Threshold < 0 || config.Threshold > 1 {
+			return fmt.Errorf("stan: invalid adaptive inflight config: %+v", config)
+		}
+		if config.MinInflight == 0 {
+			config.MinInflight = 1
+		}
+		if config.MaxInflight == 0 {
+			config.MaxInflight = DefaultMaxInflight
+		}
+		if config.MinInflight > config.MaxInflight {
+			return fmt.Errorf("stan: invalid adaptive inflight bounds: min=%v max=%v", config.MinInflight, config.MaxInflight)
+		}
+		if config.Increase == 0 {
+			config.Increase = 1
+		}
+		if config.Threshold == 0 {
+			config.Threshold = 0.5
+		}
+		o.AdaptiveInflight = &config
+		return nil
+	}
+}
+
+// adaptiveInflight holds the handler latencies measured for the
+// AdaptiveMaxInflight option.
+type adaptiveInflight struct {
+	sync.Mutex
+	count int64
+	total time.Duration
+}
+
+// record adds the latency of a handler invocation.
+func (a *adaptiveInflight) record(d time.Duration) {
+	a.Lock()
+	a.count++
+	a.total += d
+	a.Unlock()
+}
+
+// runAdaptiveInflight evaluates the handler latency at each interval until
+// the subscription or the connection is closed.
+func (sub *subscription) runAdaptiveInflight(interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			sub.adaptInflight()
+		case <-sub.closeCh:
+			return
+		case <-sub.sc.pubAckCloseChan:
+			return
+		}
+	}
+}
+
+// adaptInflight updates MaxInflight based on the latencies measured since
+// the last evaluation.
+func (sub *subscription) adaptInflight() {
+	a := sub.adaptive
+	a.Lock()
+	count, total := a.count, a.total
+	a.count, a.total = 0, 0
+	a.Unlock()
+	if count == 0 {
+		return
+	}
+
+	sub.RLock()
+	// Config is immutable.
+	config := sub.opts.AdaptiveInflight
+	cur, ackWait := sub.opts.MaxInflight, sub.opts.AckWait
+	sub.RUnlock()
+
+	avg := total / time.Duration(count)
+	estimated := avg * time.Duration(cur)
+	limit := time.Duration(float64(ackWait) * config.Threshold)
+	next := cur
+	if estimated > limit {
+		next = cur / 2
+		if next < config.MinInflight {
+			next = config.MinInflight
+		}
+	} else if estimated < limit/2 && count >= int64(cur) {
+		next = cur + config.Increase
+		if next > config.MaxInflight {
+			next = config.MaxInflight
+		}
+	}
+	if next == cur {
+		return
+	}
+	err := sub.Update(MaxInflight(next))
+	if config.DecisionCB != nil {
+		config.DecisionCB(sub, InflightDecision{
+			Old:        cur,
+			New:        next,
+			AvgLatency: avg,
+			Estimated:  estimated,
+			AckWait:    ackWait,
+			Err:        err,
+		})
+	}
+}
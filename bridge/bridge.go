@@ -0,0 +1,233 @@
+// Copyright 2026 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bridge republishes the messages of NATS Streaming channels into
+// JetStream streams, to keep both systems in sync during a migration.
+package bridge
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	stan "github.com/nats-io/stan.go"
+)
+
+// Headers set on the messages published to JetStream.
+const (
+	// HeaderChannel is the channel the message was published to.
+	HeaderChannel = "Stan-Channel"
+	// HeaderSequence is the sequence of the message in its channel.
+	HeaderSequence = "Stan-Sequence"
+	// HeaderTimestamp is the time the message was persisted by the
+	// NATS Streaming server, in RFC 3339 format with nanoseconds.
+	HeaderTimestamp = "Stan-Timestamp"
+	// HeaderReply is the reply subject of the message, if any.
+	HeaderReply = "Stan-Reply"
+)
+
+// DefaultDurablePrefix is the default prefix of the durable names of the
+// bridge's subscriptions.
+const DefaultDurablePrefix = "bridge"
+
+// ErrNoRoutes is returned when starting a bridge without routes.
+var ErrNoRoutes = errors.New("bridge: no routes")
+
+// Route defines a channel to bridge.
+type Route struct {
+	// Channel is the NATS Streaming channel messages are consumed from.
+	Channel string
+	// Subject is the subject messages are published to. It must be bound
+	// to a JetStream stream. Defaults to Channel.
+	Subject string
+}
+
+// ParseRoute parses a route in the form "channel" or "channel=subject".
+func ParseRoute(s string) (Route, error) {
+	r := Route{Channel: s}
+	if i := strings.IndexByte(s, '='); i >= 0 {
+		r.Channel, r.Subject = s[:i], s[i+1:]
+	}
+	if r.Channel == "" {
+		return Route{}, fmt.Errorf("bridge: invalid route %q", s)
+	}
+	return r, nil
+}
+
+// ErrorHandler is used to be notified of messages that could not be
+// published to JetStream. Such messages are redelivered by the NATS
+// Streaming server after the subscription's AckWait.
+type ErrorHandler func(route Route, msg *stan.Msg, err error)
+
+// Options configure a Bridge.
+type Options struct {
+	// DurablePrefix is the prefix of the durable names of the bridge's
+	// subscriptions, followed by "-" and the channel. Bridges with the
+	// same prefix resume from the same position.
+	DurablePrefix string
+
+	// MaxInflight is the number of messages of a channel the server sends
+	// before they are acknowledged. Messages are republished one at a
+	// time, so a value above 1 only buffers messages in the client, and
+	// may reorder them when a publish fails. Defaults to 1.
+	MaxInflight int
+
+	// AckWait is the time after which a message that was not republished
+	// is redelivered. Defaults to stan.DefaultAckWait.
+	AckWait time.Duration
+
+	// ErrorCB is invoked when a message could not be republished.
+	ErrorCB ErrorHandler
+}
+
+// Option is a function on the options of a Bridge.
+type Option func(*Options)
+
+// DurablePrefix is an Option to set the prefix of the durable names.
+func DurablePrefix(prefix string) Option {
+	return func(o *Options) {
+		o.DurablePrefix = prefix
+	}
+}
+
+// MaxInflight is an Option to set the number of messages of a channel the
+// server sends before they are acknowledged.
+func MaxInflight(n int) Option {
+	return func(o *Options) {
+		o.MaxInflight = n
+	}
+}
+
+// AckWait is an Option to set the time after which a message that was not
+// republished is redelivered.
+func AckWait(t time.Duration) Option {
+	return func(o *Options) {
+		o.AckWait = t
+	}
+}
+
+// ErrorCB is an Option to set the handler invoked when a message could not
+// be republished.
+func ErrorCB(cb ErrorHandler) Option {
+	return func(o *Options) {
+		o.ErrorCB = cb
+	}
+}
+
+// Bridge consumes NATS Streaming channels and republishes their messages
+// into JetStream.
+//
+// Each channel is consumed by a durable subscription, starting with the
+// first available message, and a message is acknowledged only once JetStream
+// has acknowledged its publish. A restarted bridge therefore resumes where
+// it stopped. Messages are published with the "channel:sequence" message ID,
+// so that JetStream discards messages redelivered within its duplicate
+// window, and with headers carrying the original channel, sequence and
+// timestamp. Headers of messages published with stan.Conn.PublishMsg are
+// preserved.
+type Bridge struct {
+	js     nats.JetStreamContext
+	opts   Options
+	mu     sync.Mutex
+	subs   []stan.Subscription
+	closed bool
+}
+
+// Start creates the subscriptions of the routes and returns the running
+// bridge. The JetStream streams must exist.
+func Start(sc stan.Conn, js nats.JetStreamContext, routes []Route, options ...Option) (*Bridge, error) {
+	if len(routes) == 0 {
+		return nil, ErrNoRoutes
+	}
+	opts := Options{DurablePrefix: DefaultDurablePrefix, MaxInflight: 1, AckWait: stan.DefaultAckWait}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	b := &Bridge{js: js, opts: opts}
+	for _, r := range routes {
+		if r.Subject == "" {
+			r.Subject = r.Channel
+		}
+		r := r
+		sub, err := sc.Subscribe(r.Channel, func(m *stan.Msg) { b.forward(r, m) },
+			stan.DurableName(DurableName(opts.DurablePrefix, r.Channel)),
+			stan.DeliverAllAvailable(),
+			stan.SetManualAckMode(),
+			stan.MaxInflight(opts.MaxInflight),
+			stan.AckWait(opts.AckWait))
+		if err != nil {
+			b.Close()
+			return nil, fmt.Errorf("bridge: unable to subscribe to %q: %v", r.Channel, err)
+		}
+		b.subs = append(b.subs, sub)
+	}
+	return b, nil
+}
+
+// DurableName returns the durable name used for the channel by bridges with
+// the given prefix.
+func DurableName(prefix, channel string) string {
+	return prefix + "-" + channel
+}
+
+// MsgID returns the JetStream message ID of the message of the channel with
+// the given sequence.
+func MsgID(channel string, seq uint64) string {
+	return channel + ":" + strconv.FormatUint(seq, 10)
+}
+
+// forward republishes the message and acknowledges it once JetStream has
+// acknowledged the publish.
+func (b *Bridge) forward(r Route, m *stan.Msg) {
+	out := nats.NewMsg(r.Subject)
+	for k, v := range m.Header() {
+		out.Header[k] = v
+	}
+	out.Header.Set(HeaderChannel, m.Subject)
+	out.Header.Set(HeaderSequence, strconv.FormatUint(m.Sequence, 10))
+	out.Header.Set(HeaderTimestamp, time.Unix(0, m.Timestamp).UTC().Format(time.RFC3339Nano))
+	if m.Reply != "" {
+		out.Header.Set(HeaderReply, m.Reply)
+	}
+	out.Data = m.Data
+	_, err := b.js.PublishMsg(out, nats.MsgId(MsgID(m.Subject, m.Sequence)))
+	if err == nil {
+		err = m.Ack()
+	}
+	if err != nil && b.opts.ErrorCB != nil {
+		b.opts.ErrorCB(r, m, err)
+	}
+}
+
+// Close closes the subscriptions of the bridge. Their durable state is kept
+// on the server, so a bridge started later with the same durable prefix
+// resumes where this one stopped.
+func (b *Bridge) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	var firstErr error
+	for _, sub := range b.subs {
+		if err := sub.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
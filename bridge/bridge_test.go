@@ -0,0 +1,203 @@
+// Copyright 2026 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bridge
+
+import (
+	"io/ioutil"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	natsd "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats-streaming-server/server"
+	"github.com/nats-io/nats.go"
+	stan "github.com/nats-io/stan.go"
+)
+
+const (
+	clusterName = "my_test_cluster"
+	clientName  = "me"
+)
+
+func TestParseRoute(t *testing.T) {
+	for _, tc := range []struct {
+		s     string
+		route Route
+	}{
+		{"foo", Route{Channel: "foo"}},
+		{"foo=bar.baz", Route{Channel: "foo", Subject: "bar.baz"}},
+	} {
+		r, err := ParseRoute(tc.s)
+		if err != nil || r != tc.route {
+			t.Fatalf("Unexpected result for %q: %+v %v", tc.s, r, err)
+		}
+	}
+	if _, err := ParseRoute("=bar"); err == nil {
+		t.Fatal("Expected error")
+	}
+}
+
+func TestBridge(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stan_bridge")
+	if err != nil {
+		t.Fatalf("Error creating dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	nOpts := natsd.DefaultTestOptions
+	nOpts.Port = -1
+	nOpts.JetStream = true
+	nOpts.StoreDir = dir
+	ns := natsd.RunServer(&nOpts)
+	defer ns.Shutdown()
+
+	sOpts := server.GetDefaultOptions()
+	sOpts.ID = clusterName
+	sOpts.NATSServerURL = ns.ClientURL()
+	s, err := server.RunServerWithOpts(sOpts, nil)
+	if err != nil {
+		t.Fatalf("Error starting server: %v", err)
+	}
+	defer s.Shutdown()
+
+	sc, err := stan.Connect(clusterName, clientName, stan.NatsURL(ns.ClientURL()))
+	if err != nil {
+		t.Fatalf("Error on connect: %v", err)
+	}
+	defer sc.Close()
+	nc, err := nats.Connect(ns.ClientURL())
+	if err != nil {
+		t.Fatalf("Error on connect: %v", err)
+	}
+	defer nc.Close()
+	js, err := nc.JetStream()
+	if err != nil {
+		t.Fatalf("Error getting JetStream context: %v", err)
+	}
+	if _, err := js.AddStream(&nats.StreamConfig{Name: "ORDERS", Subjects: []string{"js.orders"}}); err != nil {
+		t.Fatalf("Error adding stream: %v", err)
+	}
+
+	if _, err := Start(sc, js, nil); err != ErrNoRoutes {
+		t.Fatalf("Expected %v, got %v", ErrNoRoutes, err)
+	}
+
+	hdr := nats.Header{}
+	hdr.Set("Trace-Id", "abc")
+	if err := sc.PublishMsg(&stan.OutMsg{Subject: "orders", Header: hdr, Data: []byte("1")}); err != nil {
+		t.Fatalf("Error publishing: %v", err)
+	}
+	publish := func(data ...string) {
+		t.Helper()
+		for _, d := range data {
+			if err := sc.Publish("orders", []byte(d)); err != nil {
+				t.Fatalf("Error publishing: %v", err)
+			}
+		}
+	}
+	publish("2", "3")
+
+	waitForMsgs := func(expected uint64) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			si, err := js.StreamInfo("ORDERS")
+			if err != nil {
+				t.Fatalf("Error getting stream info: %v", err)
+			}
+			if si.State.Msgs == expected {
+				return
+			}
+			if si.State.Msgs > expected || time.Now().After(deadline) {
+				t.Fatalf("Expected %v messages, got %v", expected, si.State.Msgs)
+			}
+			time.Sleep(15 * time.Millisecond)
+		}
+	}
+	routes := []Route{{Channel: "orders", Subject: "js.orders"}}
+	b, err := Start(sc, js, routes)
+	if err != nil {
+		t.Fatalf("Error starting bridge: %v", err)
+	}
+	waitForMsgs(3)
+
+	start := time.Now()
+	for seq := uint64(1); seq <= 3; seq++ {
+		m, err := js.GetMsg("ORDERS", seq)
+		if err != nil {
+			t.Fatalf("Error getting message: %v", err)
+		}
+		if string(m.Data) != strconv.FormatUint(seq, 10) ||
+			m.Header.Get(nats.MsgIdHdr) != MsgID("orders", seq) ||
+			m.Header.Get(HeaderChannel) != "orders" ||
+			m.Header.Get(HeaderSequence) != strconv.FormatUint(seq, 10) {
+			t.Fatalf("Unexpected message: %s %v", m.Data, m.Header)
+		}
+		ts, err := time.Parse(time.RFC3339Nano, m.Header.Get(HeaderTimestamp))
+		if err != nil || ts.After(start) || start.Sub(ts) > time.Minute {
+			t.Fatalf("Unexpected timestamp: %v %v", ts, err)
+		}
+		if seq == 1 && m.Header.Get("Trace-Id") != "abc" {
+			t.Fatalf("Header not preserved: %v", m.Header)
+		}
+	}
+
+	// A restarted bridge resumes where it stopped.
+	if err := b.Close(); err != nil {
+		t.Fatalf("Error closing bridge: %v", err)
+	}
+	publish("4", "5")
+	b, err = Start(sc, js, routes)
+	if err != nil {
+		t.Fatalf("Error starting bridge: %v", err)
+	}
+	waitForMsgs(5)
+	b.Close()
+
+	// A bridge replaying the channel from the start is deduplicated.
+	b, err = Start(sc, js, routes, DurablePrefix("other"))
+	if err != nil {
+		t.Fatalf("Error starting bridge: %v", err)
+	}
+	defer b.Close()
+	publish("6")
+	waitForMsgs(6)
+	time.Sleep(100 * time.Millisecond)
+	waitForMsgs(6)
+
+	// Messages that cannot be republished are reported and redelivered.
+	errCh := make(chan error, 10)
+	b2, err := Start(sc, js, []Route{{Channel: "orders", Subject: "nostream"}},
+		DurablePrefix("failing"), AckWait(time.Second),
+		ErrorCB(func(r Route, m *stan.Msg, err error) {
+			if m.Sequence == 1 {
+				errCh <- err
+			}
+		}))
+	if err != nil {
+		t.Fatalf("Error starting bridge: %v", err)
+	}
+	defer b2.Close()
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-errCh:
+			if err == nil {
+				t.Fatal("Expected error")
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatal("Did not get error")
+		}
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright 2026 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stan
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/nats-io/stan.go/pb"
+)
+
+// Checkpointer records, on the client side, the sequence of the last message
+// processed by a consumer of a channel, so that a subscription can resume
+// from there regardless of the state kept by the server.
+// Implementations must be safe to use in multiple Go routines concurrently.
+type Checkpointer interface {
+	// Load returns the last sequence stored for the given channel and
+	// consumer, or 0 if none was stored.
+	Load(channel, consumer string) (uint64, error)
+	// Store records the last sequence processed for the given channel
+	// and consumer.
+	Store(channel, consumer string, seq uint64) error
+}
+
+// CheckpointErrorHandler is a callback function invoked when the sequence of
+// an acknowledged message could not be stored by the Checkpointer.
+type CheckpointErrorHandler func(sub Subscription, seq uint64, err error)
+
+// Checkpoint is a SubscriptionOption to record the last processed sequence
+// with the given Checkpointer, under the given consumer name, once a message
+// has been acknowledged (after the handler returns in auto-ack mode, or on
+// Msg.Ack() in manual-ack mode). When the subscription is created and a
+// sequence was stored, it starts at the following sequence, overriding the
+// start position options. Note that the server ignores the start position
+// of a durable subscription that already exists.
+//
+// If storing a sequence fails, the error handler is invoked, or the error
+// is printed on the standard error if the handler is nil. The sequence of
+// the next acknowledged message is stored as usual.
+//
+// A stored sequence never decreases. If messages are acknowledged out of
+// order, which is possible with a MaxInflight greater than 1, resuming may
+// skip messages that were not acknowledged. Use MaxInflight(1) to resume
+// exactly after the last processed message.
+func Checkpoint(cp Checkpointer, consumer string, errHandler CheckpointErrorHandler) SubscriptionOption {
+	return func(o *SubscriptionOptions) error {
+		if cp == nil {
+			return errors.New("stan: nil checkpointer")
+		}
+		o.Checkpointer = cp
+		o.CheckpointConsumer = consumer
+		o.CheckpointErrorCB = errHandler
+		return nil
+	}
+}
+
+// resumeFromCheckpoint sets the start position of the subscription after the
+// stored sequence, if any.
+func (sub *subscription) resumeFromCheckpoint() error {
+	seq, err := sub.opts.Checkpointer.Load(sub.subject, sub.opts.CheckpointConsumer)
+	if err != nil {
+		return err
+	}
+	if seq > 0 {
+		sub.opts.StartAt = pb.StartPosition_SequenceStart
+		sub.opts.StartSequence = seq + 1
+		sub.checkpointed = seq
+	}
+	return nil
+}
+
+// checkpoint stores the sequence of an acknowledged message if it is
+// past the last stored one, and reports a failure to store it.
+func (sub *subscription) checkpoint(seq uint64) {
+	sub.cpMu.Lock()
+	if seq <= sub.checkpointed {
+		sub.cpMu.Unlock()
+		return
+	}
+	// Options are immutable once the subscription is created.
+	err := sub.opts.Checkpointer.Store(sub.subject, sub.opts.CheckpointConsumer, seq)
+	if err == nil {
+		sub.checkpointed = seq
+	}
+	sub.cpMu.Unlock()
+	if err == nil {
+		return
+	}
+	if cb := sub.opts.CheckpointErrorCB; cb != nil {
+		cb(sub, seq, err)
+	} else {
+		fmt.Fprintf(os.Stderr, "stan: error storing checkpoint for %q (seq=%v): %v\n", sub.subject, seq, err)
+	}
+}
+
+// checkpointKey returns the key under which the sequence of a channel and
+// consumer is stored.
+func checkpointKey(channel, consumer string) string {
+	return channel + "/" + consumer
+}
+
+// MemoryCheckpointer is a Checkpointer that keeps sequences in memory.
+type MemoryCheckpointer struct {
+	mu   sync.Mutex
+	seqs map[string]uint64
+}
+
+// NewMemoryCheckpointer returns a Checkpointer that keeps sequences in memory,
+// which allows resuming subscriptions within the lifetime of the process.
+func NewMemoryCheckpointer() *MemoryCheckpointer {
+	return &MemoryCheckpointer{seqs: make(map[string]uint64)}
+}
+
+// Load implements the Checkpointer interface.
+func (m *MemoryCheckpointer) Load(channel, consumer string) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.seqs[checkpointKey(channel, consumer)], nil
+}
+
+// Store implements the Checkpointer interface.
+func (m *MemoryCheckpointer) Store(channel, consumer string, seq uint64) error {
+	m.mu.Lock()
+	m.seqs[checkpointKey(channel, consumer)] = seq
+	m.mu.Unlock()
+	return nil
+}
+
+// FileCheckpointer is a Checkpointer that keeps sequences in a JSON file.
+type FileCheckpointer struct {
+	mu   sync.Mutex
+	path string
+	seqs map[string]uint64
+}
+
+// NewFileCheckpointer returns a Checkpointer that keeps sequences in the
+// file at the given path, loading the sequences it already contains.
+// The file is rewritten, through a temporary file and a rename, on each
+// Store, so it is always complete. It is not synced to disk.
+func NewFileCheckpointer(path string) (*FileCheckpointer, error) {
+	f := &FileCheckpointer{path: path, seqs: make(map[string]uint64)}
+	b, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, &f.seqs); err != nil {
+			return nil, fmt.Errorf("stan: invalid checkpoint file %q: %v", path, err)
+		}
+	}
+	return f, nil
+}
+
+// Load implements the Checkpointer interface.
+func (f *FileCheckpointer) Load(channel, consumer string) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.seqs[checkpointKey(channel, consumer)], nil
+}
+
+// Store implements the Checkpointer interface.
+func (f *FileCheckpointer) Store(channel, consumer string, seq uint64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := checkpointKey(channel, consumer)
+	prev, had := f.seqs[key]
+	f.seqs[key] = seq
+	if err := f.write(); err != nil {
+		if had {
+			f.seqs[key] = prev
+		} else {
+			delete(f.seqs, key)
+		}
+		return err
+	}
+	return nil
+}
+
+// write rewrites the file with the current sequences.
+// Lock is held on entry.
+func (f *FileCheckpointer) write() error {
+	b, err := json.Marshal(f.seqs)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(f.path), filepath.Base(f.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(b); err == nil {
+		err = tmp.Close()
+	} else {
+		tmp.Close()
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), f.path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
@@ -0,0 +1,104 @@
+// Copyright 2026 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stan
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nuid"
+)
+
+// ClientIDStrategy determines the client ID used by Connect.
+type ClientIDStrategy int
+
+const (
+	// FixedClientID uses the client ID given to Connect as-is.
+	// This is the default.
+	FixedClientID ClientIDStrategy = iota
+	// HostnameClientID uses the host name. If the client ID given to
+	// Connect is not empty, it is used as a prefix, as in "prefix-hostname".
+	HostnameClientID
+	// HostnameRandomClientID is like HostnameClientID with a random suffix,
+	// as in "prefix-hostname-suffix". A new suffix is generated on each
+	// connection attempt.
+	HostnameRandomClientID
+)
+
+// SetClientIDStrategy is an Option to set how the client ID is generated
+// from the one given to Connect. The characters of the host name that are
+// not allowed in a client ID are replaced with '-'. The client ID that was
+// eventually used is returned by Conn.ClientID().
+func SetClientIDStrategy(strategy ClientIDStrategy) Option {
+	return func(o *Options) error {
+		o.ClientIDStrategy = strategy
+		return nil
+	}
+}
+
+// DuplicateClientIDRetry is an Option to retry connecting when the server
+// reports that the client ID is already registered, which happens when an
+// application restarts before the server has detected that its previous
+// connection is gone. Connect tries at most attempts more times, waiting
+// for the given backoff before the first retry and doubling it after each
+// attempt.
+func DuplicateClientIDRetry(attempts int, backoff time.Duration) Option {
+	return func(o *Options) error {
+		o.DuplicateClientIDRetries = attempts
+		o.DuplicateClientIDBackoff = backoff
+		return nil
+	}
+}
+
+// Returns the client ID to use for a connection attempt.
+func (o *Options) generateClientID(clientID string) (string, error) {
+	if o.ClientIDStrategy == FixedClientID {
+		return clientID, nil
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		return "", err
+	}
+	id := sanitizeClientID(host)
+	if clientID != "" {
+		id = clientID + "-" + id
+	}
+	if o.ClientIDStrategy == HostnameRandomClientID {
+		id += "-" + nuid.Next()
+	}
+	return id, nil
+}
+
+// Replaces the characters not allowed in a client ID with '-'.
+func sanitizeClientID(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		}
+		return '-'
+	}, s)
+}
+
+// Returns true if the connect error means that the client ID is in use.
+func isDuplicateClientID(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "clientID already registered")
+}
+
+// ClientID returns the client ID used by this connection.
+func (sc *conn) ClientID() string {
+	// clientID is immutable.
+	return sc.clientID
+}
@@ -0,0 +1,297 @@
+// Copyright 2026 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stan
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Configuration fields. They are used as-is in connection URL query
+// parameters and configuration files, and upper-cased with the
+// EnvPrefix for environment variables, e.g. STAN_PING_INTERVAL.
+const (
+	ConfigClusterID          = "cluster_id"
+	ConfigClientID           = "client_id"
+	ConfigURL                = "url"
+	ConfigConnectWait        = "connect_wait"
+	ConfigPubAckWait         = "pub_ack_wait"
+	ConfigMaxPubAcksInflight = "max_pub_acks_inflight"
+	ConfigPingInterval       = "ping_interval"
+	ConfigPingMaxOut         = "ping_max_out"
+	ConfigDiscoverPrefix     = "discover_prefix"
+	ConfigAllowCloseRetry    = "allow_close_retry"
+	ConfigDeadLetterSubject  = "dead_letter_subject"
+)
+
+// EnvPrefix is the prefix of the environment variables read by OptionsFromEnv.
+const EnvPrefix = "STAN_"
+
+var configFields = []string{
+	ConfigClusterID,
+	ConfigClientID,
+	ConfigURL,
+	ConfigConnectWait,
+	ConfigPubAckWait,
+	ConfigMaxPubAcksInflight,
+	ConfigPingInterval,
+	ConfigPingMaxOut,
+	ConfigDiscoverPrefix,
+	ConfigAllowCloseRetry,
+	ConfigDeadLetterSubject,
+}
+
+// ConfigError is returned when a configuration field has an invalid value
+// or is unknown.
+type ConfigError struct {
+	// Field is the name of the offending field, as used in the source
+	// (environment variable name, URL parameter or file key).
+	Field string
+	// Value is the offending value.
+	Value string
+	// Err is the reason why the value is invalid.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("stan: invalid value %q for %s: %v", e.Value, e.Field, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+var errUnknownField = errors.New("unknown field")
+
+// Config holds the parameters of a connection loaded with OptionsFromEnv,
+// ParseURL or LoadConfigFile.
+type Config struct {
+	// ClusterID is the ID of the NATS Streaming cluster to connect to.
+	ClusterID string
+	// ClientID is the client ID of the connection.
+	ClientID string
+	// Options are the connection options corresponding to the fields that
+	// were set. Fields that were not set keep their default value.
+	Options []Option
+
+	pingInterval int
+	pingMaxOut   int
+}
+
+// Connect will form a connection to the NATS Streaming cluster using this
+// configuration. The given options are applied after the ones of the
+// configuration and therefore take precedence.
+func (c *Config) Connect(options ...Option) (Conn, error) {
+	opts := append(append([]Option(nil), c.Options...), options...)
+	return Connect(c.ClusterID, c.ClientID, opts...)
+}
+
+// set sets the given field. The name is the one reported in errors.
+func (c *Config) set(field, name, value string) error {
+	var err error
+	switch field {
+	case ConfigClusterID:
+		c.ClusterID = value
+	case ConfigClientID:
+		c.ClientID = value
+	case ConfigURL:
+		c.Options = append(c.Options, NatsURL(value))
+	case ConfigConnectWait, ConfigPubAckWait:
+		var d time.Duration
+		if d, err = time.ParseDuration(value); err == nil && d <= 0 {
+			err = errors.New("must be positive")
+		}
+		if err == nil {
+			if field == ConfigConnectWait {
+				c.Options = append(c.Options, ConnectWait(d))
+			} else {
+				c.Options = append(c.Options, PubAckWait(d))
+			}
+		}
+	case ConfigMaxPubAcksInflight:
+		var n int
+		if n, err = strconv.Atoi(value); err == nil && n <= 0 {
+			err = errors.New("must be positive")
+		}
+		if err == nil {
+			c.Options = append(c.Options, MaxPubAcksInflight(n))
+		}
+	case ConfigPingInterval:
+		// The protocol expresses the interval in seconds.
+		var d time.Duration
+		if d, err = time.ParseDuration(value); err == nil {
+			if d < time.Second || d%time.Second != 0 {
+				err = errors.New("must be a whole number of seconds, at least 1s")
+			} else {
+				c.pingInterval = int(d / time.Second)
+			}
+		}
+	case ConfigPingMaxOut:
+		if c.pingMaxOut, err = strconv.Atoi(value); err == nil && c.pingMaxOut < 2 {
+			err = errors.New("must be at least 2")
+		}
+	case ConfigDiscoverPrefix:
+		c.Options = append(c.Options, func(o *Options) error {
+			o.DiscoverPrefix = value
+			return nil
+		})
+	case ConfigAllowCloseRetry:
+		var allow bool
+		if allow, err = strconv.ParseBool(value); err == nil {
+			c.Options = append(c.Options, AllowCloseRetry(allow))
+		}
+	case ConfigDeadLetterSubject:
+		c.Options = append(c.Options, DeadLetterSubject(value))
+	default:
+		err = errUnknownField
+	}
+	if err != nil {
+		// Report the syntax error without the function name.
+		if ne, ok := err.(*strconv.NumError); ok {
+			err = ne.Err
+		}
+		return &ConfigError{Field: name, Value: value, Err: err}
+	}
+	return nil
+}
+
+// finish adds the options that depend on several fields.
+func (c *Config) finish() *Config {
+	if c.pingInterval > 0 || c.pingMaxOut > 0 {
+		interval, maxOut := c.pingInterval, c.pingMaxOut
+		if interval == 0 {
+			interval = DefaultPingInterval
+		}
+		if maxOut == 0 {
+			maxOut = DefaultPingMaxOut
+		}
+		c.Options = append(c.Options, Pings(interval, maxOut))
+	}
+	return c
+}
+
+// OptionsFromEnv returns the configuration found in the environment
+// variables named after the configuration fields with the EnvPrefix, that is
+// STAN_CLUSTER_ID, STAN_CLIENT_ID, STAN_URL, STAN_CONNECT_WAIT,
+// STAN_PUB_ACK_WAIT, STAN_MAX_PUB_ACKS_INFLIGHT, STAN_PING_INTERVAL,
+// STAN_PING_MAX_OUT, STAN_DISCOVER_PREFIX, STAN_ALLOW_CLOSE_RETRY and
+// STAN_DEAD_LETTER_SUBJECT. Durations, including the ping interval, use the
+// time.ParseDuration format, for instance "5s".
+func OptionsFromEnv() (*Config, error) {
+	c := &Config{}
+	for _, field := range configFields {
+		name := EnvPrefix + strings.ToUpper(field)
+		if value, ok := os.LookupEnv(name); ok {
+			if err := c.set(field, name, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return c.finish(), nil
+}
+
+// ParseURL returns the configuration described by a connection URL of the
+// form:
+//
+//	stan://[user:password@]host:port[,host:port]/clusterID?client_id=id&ping_interval=5s
+//
+// The "stans" scheme can be used to require TLS on the NATS connection.
+// The query parameters are the configuration fields, except "url".
+func ParseURL(connURL string) (*Config, error) {
+	u, err := url.Parse(connURL)
+	if err != nil {
+		return nil, &ConfigError{Field: "url", Value: connURL, Err: err}
+	}
+	var scheme string
+	switch u.Scheme {
+	case "stan":
+		scheme = "nats"
+	case "stans":
+		scheme = "tls"
+	default:
+		return nil, &ConfigError{Field: "scheme", Value: u.Scheme, Err: errors.New(`must be "stan" or "stans"`)}
+	}
+	if u.Host == "" {
+		return nil, &ConfigError{Field: "host", Value: u.Host, Err: errors.New("must not be empty")}
+	}
+	c := &Config{ClusterID: strings.Trim(u.Path, "/")}
+	hosts := strings.Split(u.Host, ",")
+	natsURLs := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		nu := url.URL{Scheme: scheme, User: u.User, Host: host}
+		natsURLs = append(natsURLs, nu.String())
+	}
+	c.Options = append(c.Options, NatsURL(strings.Join(natsURLs, ",")))
+
+	query := u.Query()
+	params := make([]string, 0, len(query))
+	for param := range query {
+		params = append(params, param)
+	}
+	// Have a deterministic order so that reported errors are consistent.
+	sort.Strings(params)
+	for _, param := range params {
+		value := query.Get(param)
+		if param == ConfigURL {
+			return nil, &ConfigError{Field: param, Value: value, Err: errUnknownField}
+		}
+		if err := c.set(param, param, value); err != nil {
+			return nil, err
+		}
+	}
+	return c.finish(), nil
+}
+
+// LoadConfigFile returns the configuration found in the given file. Each
+// line of the file is either empty, a comment starting with '#', or a
+// "field = value" pair where field is one of the configuration fields.
+func LoadConfigFile(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	c := &Config{}
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		name := fmt.Sprintf("%s:%d", path, line)
+		kv := strings.SplitN(text, "=", 2)
+		if len(kv) != 2 {
+			return nil, &ConfigError{Field: name, Value: text, Err: errors.New(`expected "field = value"`)}
+		}
+		field := strings.TrimSpace(kv[0])
+		value := strings.Trim(strings.TrimSpace(kv[1]), `"`)
+		if err := c.set(field, name+" "+field, value); err != nil {
+			return nil, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return c.finish(), nil
+}
@@ -0,0 +1,172 @@
+// Copyright 2026 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stan
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"sort"
+
+	"github.com/nats-io/nats.go"
+)
+
+// The envelope carrying headers along with the data of a message is:
+//
+//	magic | version | count | (key length | key | value length | value)* | data
+//
+// where count and lengths are unsigned varints, and count is the number of
+// key/value pairs (a key with several values appears once per value).
+// The magic starts with a zero byte, which is unlikely at the start of
+// text payloads.
+var envelopeMagic = []byte("\x00\xffSTAN")
+
+const envelopeVersion = 1
+
+// ErrBadEnvelope is returned when decoding a malformed envelope.
+var ErrBadEnvelope = errors.New("stan: malformed message envelope")
+
+// OutMsg is a message to be published with PublishMsg.
+type OutMsg struct {
+	// Subject is the channel the message is published to.
+	Subject string
+	// Reply is an optional reply subject, see PublishWithReply.
+	Reply string
+	// Header holds user-defined metadata stored with the message.
+	Header nats.Header
+	// Data is the payload of the message.
+	Data []byte
+}
+
+// EncodeEnvelope returns the data wrapped in an envelope carrying the
+// given header. If the header is empty, the data is returned as-is, unless
+// it starts like an envelope, in which case it is wrapped in an envelope
+// without header so that it is delivered unchanged.
+func EncodeEnvelope(header nats.Header, data []byte) []byte {
+	if len(header) == 0 && !bytes.HasPrefix(data, envelopeMagic) {
+		return data
+	}
+	keys := make([]string, 0, len(header))
+	count := 0
+	for k, vals := range header {
+		keys = append(keys, k)
+		count += len(vals)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	var tmp [binary.MaxVarintLen64]byte
+	putUvarint := func(v uint64) {
+		buf.Write(tmp[:binary.PutUvarint(tmp[:], v)])
+	}
+	putString := func(s string) {
+		putUvarint(uint64(len(s)))
+		buf.WriteString(s)
+	}
+	buf.Write(envelopeMagic)
+	buf.WriteByte(envelopeVersion)
+	putUvarint(uint64(count))
+	for _, k := range keys {
+		for _, v := range header[k] {
+			putString(k)
+			putString(v)
+		}
+	}
+	buf.Write(data)
+	return buf.Bytes()
+}
+
+// DecodeEnvelope returns the header and payload carried by an envelope.
+// The header is nil if the envelope carries none. If the data is not an
+// envelope, it is returned as-is with a nil header.
+// Data in an envelope of an unknown version is also returned as-is.
+func DecodeEnvelope(data []byte) (nats.Header, []byte, error) {
+	if !bytes.HasPrefix(data, envelopeMagic) || len(data) == len(envelopeMagic) {
+		return nil, data, nil
+	}
+	b := data[len(envelopeMagic):]
+	if b[0] != envelopeVersion {
+		return nil, data, nil
+	}
+	b = b[1:]
+	uvarint := func() (uint64, bool) {
+		v, n := binary.Uvarint(b)
+		if n <= 0 {
+			return 0, false
+		}
+		b = b[n:]
+		return v, true
+	}
+	str := func() (string, bool) {
+		l, ok := uvarint()
+		if !ok || l > uint64(len(b)) {
+			return "", false
+		}
+		s := string(b[:l])
+		b = b[l:]
+		return s, true
+	}
+	count, ok := uvarint()
+	// Each pair takes at least 2 bytes.
+	if !ok || count > uint64(len(b))/2 {
+		return nil, nil, ErrBadEnvelope
+	}
+	if count == 0 {
+		return nil, b, nil
+	}
+	header := make(nats.Header, count)
+	for i := uint64(0); i < count; i++ {
+		k, ok := str()
+		if !ok {
+			return nil, nil, ErrBadEnvelope
+		}
+		v, ok := str()
+		if !ok {
+			return nil, nil, ErrBadEnvelope
+		}
+		header[k] = append(header[k], v)
+	}
+	return header, b, nil
+}
+
+// PublishMsg will publish the message to the cluster and wait for an ACK.
+// The header is stored in an envelope along with the data, so it is kept
+// when the message is persisted and redelivered.
+func (sc *conn) PublishMsg(msg *OutMsg) error {
+	if msg == nil {
+		return ErrNilMsg
+	}
+	// See Publish for why the channel is buffered.
+	ch := make(chan error, 1)
+	_, err := sc.publishAsync(msg.Subject, msg.Reply, msg.Header, msg.Data, nil, ch)
+	if err == nil {
+		err = <-ch
+	}
+	return err
+}
+
+// Header returns the header the message was published with using PublishMsg,
+// or nil if it had none.
+func (msg *Msg) Header() nats.Header {
+	return msg.header
+}
+
+// decodeEnvelope replaces the data of the message with the payload of its
+// envelope, if any, and sets its header. A malformed envelope is left as-is.
+func (msg *Msg) decodeEnvelope() {
+	if header, data, err := DecodeEnvelope(msg.Data); err == nil {
+		msg.header = header
+		msg.Data = data
+	}
+}
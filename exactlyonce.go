@@ -0,0 +1,117 @@
+// Copyright 2026 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stan
+
+// TransactionalSink is where the effects of processing messages are applied,
+// along with the sequence of the last message applied for each channel, in a
+// single transaction. It is typically backed by a database.
+type TransactionalSink interface {
+	// Begin starts a transaction to process a message of the given channel.
+	Begin(channel string) (Tx, error)
+}
+
+// Tx is a transaction of a TransactionalSink.
+type Tx interface {
+	// LastApplied returns the sequence of the last message applied for
+	// the channel, or 0 if none was applied.
+	LastApplied() (uint64, error)
+	// Commit records the given sequence as the last one applied for the
+	// channel and commits the transaction. For several subscriptions to
+	// safely share the sink, the transaction must fail if the recorded
+	// sequence has changed since LastApplied was read. If Commit fails,
+	// the transaction must be aborted.
+	Commit(seq uint64) error
+	// Rollback aborts the transaction.
+	Rollback() error
+}
+
+// TxHandler processes a message within a transaction of a TransactionalSink.
+// Returning an error aborts the transaction. The error is then handled as
+// with SubscribeE: the message is not acknowledged, unless the error was
+// wrapped with DeadLetter or Redeliver.
+type TxHandler func(tx Tx, msg *Msg) error
+
+// SubscribeEffectivelyOnce will perform a subscription on the given connection
+// where each message is processed by the handler within a transaction of the
+// sink, so that the effects of a message are applied exactly once even though
+// messages may be redelivered. For each message, the library:
+//
+//   - begins a transaction and reads the last applied sequence,
+//   - skips the message (rolls back and acknowledges it) if its sequence is
+//     at or below the last applied one,
+//   - otherwise invokes the handler, commits the transaction with the
+//     message's sequence, and acknowledges the message only once committed.
+//
+// The subscription is in manual-ack mode and, if a sequence was already
+// applied, starts at the following sequence, overriding the start position
+// options (the server ignores it for a durable subscription that already
+// exists). Since only the last applied sequence is recorded, messages must
+// be applied in order: MaxInflight is always set to 1, so that a message
+// that failed is redelivered before any later message is received.
+func SubscribeEffectivelyOnce(sc Conn, subject string, sink TransactionalSink, handler TxHandler, opts ...SubscriptionOption) (Subscription, error) {
+	if sink == nil || handler == nil {
+		return nil, ErrNilHandler
+	}
+	last, err := lastApplied(sink, subject)
+	if err != nil {
+		return nil, err
+	}
+	opts = append([]SubscriptionOption(nil), opts...)
+	if last > 0 {
+		opts = append(opts, StartAtSequence(last+1))
+	}
+	opts = append(opts, MaxInflight(1))
+	return sc.SubscribeE(subject, func(msg *Msg) error {
+		return applyOnce(sink, handler, msg)
+	}, opts...)
+}
+
+// lastApplied returns the last sequence applied for the channel.
+func lastApplied(sink TransactionalSink, channel string) (uint64, error) {
+	tx, err := sink.Begin(channel)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	return tx.LastApplied()
+}
+
+// applyOnce processes the message in a transaction, unless it was already
+// applied. A nil error means that the message can be acknowledged.
+func applyOnce(sink TransactionalSink, handler TxHandler, msg *Msg) error {
+	tx, err := sink.Begin(msg.Subject)
+	if err != nil {
+		return err
+	}
+	done := false
+	// Also covers a panic in the handler.
+	defer func() {
+		if !done {
+			tx.Rollback()
+		}
+	}()
+	last, err := tx.LastApplied()
+	if err != nil {
+		return err
+	}
+	if msg.Sequence <= last {
+		// Already applied, only the ack was lost.
+		return nil
+	}
+	if err := handler(tx, msg); err != nil {
+		return err
+	}
+	done = true
+	return tx.Commit(msg.Sequence)
+}
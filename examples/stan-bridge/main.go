@@ -0,0 +1,148 @@
+// Copyright 2026 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+
+	nats "github.com/nats-io/nats.go"
+	"github.com/nats-io/stan.go"
+	"github.com/nats-io/stan.go/bridge"
+)
+
+var usageStr = `
+Usage: stan-bridge [options] <channel>[=<subject>] ...
+
+Republishes the messages of NATS Streaming channels into JetStream. Each
+channel is published to the given subject, or to the subject of the same
+name, which must be bound to an existing JetStream stream.
+
+Options:
+	-s,  --server   <url>            NATS Streaming server URL(s)
+	-js, --jsserver <url>            JetStream server URL(s) (default: same as --server)
+	-c,  --cluster  <cluster name>   NATS Streaming cluster name
+	-id, --clientid <client ID>      NATS Streaming client ID
+	-d,  --durable  <prefix>         Prefix of the durable names
+	-m,  --inflight <number>         Maximum number of messages of a channel in flight
+	-cr, --creds    <credentials>    NATS 2.0 Credentials
+`
+
+// NOTE: Use tls scheme for TLS, e.g. stan-bridge -s tls://demo.nats.io:4443 foo
+func usage() {
+	fmt.Printf("%s\n", usageStr)
+	os.Exit(0)
+}
+
+func main() {
+	var (
+		clusterID   string
+		clientID    string
+		URL         string
+		jsURL       string
+		durable     string
+		maxInflight int
+		userCreds   string
+	)
+
+	flag.StringVar(&URL, "s", stan.DefaultNatsURL, "The nats server URLs (separated by comma)")
+	flag.StringVar(&URL, "server", stan.DefaultNatsURL, "The nats server URLs (separated by comma)")
+	flag.StringVar(&jsURL, "js", "", "The JetStream server URLs (separated by comma)")
+	flag.StringVar(&jsURL, "jsserver", "", "The JetStream server URLs (separated by comma)")
+	flag.StringVar(&clusterID, "c", "test-cluster", "The NATS Streaming cluster ID")
+	flag.StringVar(&clusterID, "cluster", "test-cluster", "The NATS Streaming cluster ID")
+	flag.StringVar(&clientID, "id", "stan-bridge", "The NATS Streaming client ID to connect with")
+	flag.StringVar(&clientID, "clientid", "stan-bridge", "The NATS Streaming client ID to connect with")
+	flag.StringVar(&durable, "d", bridge.DefaultDurablePrefix, "Prefix of the durable names")
+	flag.StringVar(&durable, "durable", bridge.DefaultDurablePrefix, "Prefix of the durable names")
+	flag.IntVar(&maxInflight, "m", 1, "Maximum number of messages of a channel in flight")
+	flag.IntVar(&maxInflight, "inflight", 1, "Maximum number of messages of a channel in flight")
+	flag.StringVar(&userCreds, "cr", "", "Credentials File")
+	flag.StringVar(&userCreds, "creds", "", "Credentials File")
+
+	log.SetFlags(log.LstdFlags)
+	flag.Usage = usage
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) < 1 {
+		usage()
+	}
+	routes := make([]bridge.Route, 0, len(args))
+	for _, arg := range args {
+		r, err := bridge.ParseRoute(arg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		routes = append(routes, r)
+	}
+
+	// Connect Options.
+	opts := []nats.Option{nats.Name("NATS Streaming Example Bridge")}
+	// Use UserCredentials
+	if userCreds != "" {
+		opts = append(opts, nats.UserCredentials(userCreds))
+	}
+
+	// Connect to NATS
+	nc, err := nats.Connect(URL, opts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer nc.Close()
+
+	jnc := nc
+	if jsURL != "" && jsURL != URL {
+		jnc, err = nats.Connect(jsURL, opts...)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer jnc.Close()
+	}
+	js, err := jnc.JetStream()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	sc, err := stan.Connect(clusterID, clientID, stan.NatsConn(nc),
+		stan.SetConnectionLostHandler(func(_ stan.Conn, reason error) {
+			log.Fatalf("Connection lost, reason: %v", reason)
+		}))
+	if err != nil {
+		log.Fatalf("Can't connect: %v.\nMake sure a NATS Streaming Server is running at: %s", err, URL)
+	}
+	defer sc.Close()
+
+	b, err := bridge.Start(sc, js, routes,
+		bridge.DurablePrefix(durable),
+		bridge.MaxInflight(maxInflight),
+		bridge.ErrorCB(func(r bridge.Route, m *stan.Msg, err error) {
+			log.Printf("Error republishing [%s] seq %d, will be redelivered: %v", r.Channel, m.Sequence, err)
+		}))
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Bridging %v, clientID=[%s] durable prefix=[%s]\n", args, clientID, durable)
+
+	// Wait for a SIGINT (perhaps triggered by user with CTRL-C)
+	// The durable subscriptions are kept so that the bridge resumes on restart.
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, os.Interrupt)
+	<-signalChan
+	fmt.Printf("\nReceived an interrupt, closing bridge...\n\n")
+	b.Close()
+}
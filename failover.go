@@ -0,0 +1,844 @@
+// Copyright 2026 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stan
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// Errors
+var (
+	ErrNoClusterTargets = errors.New("stan: no cluster targets")
+	ErrFailoverNatsConn = errors.New("stan: NatsConn option not supported with failover")
+	ErrNotSubscribedYet = errors.New("stan: subscription not re-established after failover")
+)
+
+// ClusterTarget identifies a NATS Streaming cluster that a connection created
+// with ConnectWithFailover can connect to.
+type ClusterTarget struct {
+	// ClusterID is the ID of the NATS Streaming cluster.
+	ClusterID string
+	// NatsURL is an URL (or comma separated list of URLs) to the NATS
+	// servers of this cluster. If empty, the NatsURL option is used.
+	NatsURL string
+	// DiscoverPrefix is the prefix connect requests are sent to for this
+	// cluster. If empty, the default "_STAN.discover" is used.
+	DiscoverPrefix string
+}
+
+// FailoverHandler is used to be notified when a connection created with
+// ConnectWithFailover switches to another cluster after the connection
+// to the previous one was lost for the given reason.
+type FailoverHandler func(from, to ClusterTarget, reason error)
+
+// SetFailoverHandler is an Option to set the handler invoked when a
+// connection created with ConnectWithFailover switches to another cluster.
+func SetFailoverHandler(handler FailoverHandler) Option {
+	return func(o *Options) error {
+		o.FailoverCB = handler
+		return nil
+	}
+}
+
+// ResubscribeErrorHandler is used to be notified when a subscription of a
+// connection created with ConnectWithFailover could not be re-established
+// on another cluster.
+type ResubscribeErrorHandler func(sub Subscription, err error)
+
+// SetResubscribeErrorHandler is an Option to set the handler invoked when a
+// subscription could not be re-established after a failover. It is invoked
+// for each failed attempt.
+func SetResubscribeErrorHandler(handler ResubscribeErrorHandler) Option {
+	return func(o *Options) error {
+		o.ResubscribeErrorCB = handler
+		return nil
+	}
+}
+
+// Wait between attempts to re-establish a subscription after a failover.
+const (
+	failoverResubscribeMinWait = 250 * time.Millisecond
+	failoverResubscribeMaxWait = 5 * time.Second
+)
+
+// FailoverOptions is a SubscriptionOption to set options that are applied,
+// on top of the subscription's other options, when the subscription is
+// re-established on another cluster by a connection created with
+// ConnectWithFailover. This is typically used to set the start position
+// on the standby cluster, for instance StartAtTimeDelta().
+func FailoverOptions(opts ...SubscriptionOption) SubscriptionOption {
+	return func(o *SubscriptionOptions) error {
+		o.FailoverOptions = append([]SubscriptionOption(nil), opts...)
+		return nil
+	}
+}
+
+// ConnectWithFailover will form a connection to the first available NATS
+// Streaming cluster of the given ordered list of targets. When the
+// connection is lost (see SetConnectionLostHandler), the library connects
+// to the next available target (wrapping around the list) and re-establishes
+// the subscriptions created with the returned connection, applying their
+// FailoverOptions. If no target is available, the connection is closed and
+// the ConnectionLostHandler, if any, is invoked.
+//
+// If a subscription cannot be re-established, the ResubscribeErrorHandler,
+// if any, is invoked and the library retries with an increasing wait until
+// it succeeds, or the subscription or connection is closed. In the meantime,
+// the subscription's methods return ErrNotSubscribedYet.
+//
+// While a failover is in progress, calls on the connection return
+// ErrConnectionClosed and Status() returns Reconnecting. Note that the Sub
+// field of messages delivered to the handlers refers to the subscription on
+// the current cluster, not to the Subscription returned to the application.
+// The NatsConn option is not supported.
+func ConnectWithFailover(targets []ClusterTarget, clientID string, options ...Option) (Conn, error) {
+	if len(targets) == 0 {
+		return nil, ErrNoClusterTargets
+	}
+	fc := &failoverConn{
+		targets:  append([]ClusterTarget(nil), targets...),
+		clientID: clientID,
+		options:  append([]Option(nil), options...),
+		opts:     getDefaultOptions(),
+		subs:     make(map[*failoverSub]struct{}),
+		closeCh:  make(chan struct{}),
+	}
+	for _, opt := range options {
+		if err := opt(&fc.opts); err != nil {
+			return nil, err
+		}
+	}
+	if fc.opts.NatsConn != nil {
+		return nil, ErrFailoverNatsConn
+	}
+	var lastErr error
+	for i := range fc.targets {
+		sc, gen, err := fc.connect(i)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		fc.mu.Lock()
+		fc.cur, fc.idx, fc.curGen = sc, i, gen
+		fc.mu.Unlock()
+		return fc, nil
+	}
+	return nil, lastErr
+}
+
+// failoverConn is a Conn that switches between several clusters.
+type failoverConn struct {
+	mu          sync.RWMutex
+	targets     []ClusterTarget
+	clientID    string
+	options     []Option
+	opts        Options // Used for the user callbacks, immutable.
+	cur         Conn
+	idx         int
+	gen         uint64 // Incremented for each underlying connection.
+	curGen      uint64 // Generation of cur.
+	status      statusInfo
+	subs        map[*failoverSub]struct{}
+	failingOver bool
+	closed      bool
+	closeCh     chan struct{} // Closed when the connection is closed.
+}
+
+// connect creates a connection to the target at the given index, and returns
+// it along with its generation.
+func (fc *failoverConn) connect(idx int) (Conn, uint64, error) {
+	fc.mu.Lock()
+	fc.gen++
+	gen := fc.gen
+	fc.mu.Unlock()
+
+	t := fc.targets[idx]
+	options := append([]Option(nil), fc.options...)
+	if t.NatsURL != "" {
+		options = append(options, NatsURL(t.NatsURL))
+	}
+	prefix := t.DiscoverPrefix
+	if prefix == "" {
+		prefix = DefaultDiscoverPrefix
+	}
+	options = append(options, func(o *Options) error {
+		o.DiscoverPrefix = prefix
+		return nil
+	}, SetConnectionLostHandler(fc.connectionLost))
+	if fc.opts.StatusChangedCB != nil {
+		options = append(options, StatusChanged(func(_, new Status) {
+			fc.statusChanged(gen, new)
+		}))
+	}
+	sc, err := Connect(t.ClusterID, fc.clientID, options...)
+	return sc, gen, err
+}
+
+// statusChanged is the StatusChanged handler of the underlying connection
+// of the given generation. Changes of connections that are no longer in use,
+// or of the current one while failing over, are ignored since the failover
+// reports its own changes. The loss of the connection is reported as
+// Reconnecting, unless the failover connection itself has been closed.
+func (fc *failoverConn) statusChanged(gen uint64, new Status) {
+	// Hold the lock so that changes are ordered with those of the failover.
+	fc.mu.RLock()
+	defer fc.mu.RUnlock()
+	if gen != fc.curGen || fc.failingOver {
+		return
+	}
+	if new == Closed && !fc.closed {
+		new = Reconnecting
+	}
+	fc.setStatus(new)
+}
+
+// setStatus records the status of the failover connection and, if it has
+// changed, schedules the invocation of the StatusChanged handler. All the
+// changes go through this function so that the handler is invoked in order.
+// Connection lock is held on entry.
+func (fc *failoverConn) setStatus(new Status) {
+	// Options are immutable.
+	if fc.opts.StatusChangedCB == nil {
+		return
+	}
+	s := &fc.status
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Closed is a final state.
+	if s.last == Closed || s.last == new {
+		return
+	}
+	s.add(new, fc.opts.StatusChangedCB)
+}
+
+// connectionLost is the ConnectionLostHandler of the underlying connections.
+// It connects to the next available target and re-establishes subscriptions.
+func (fc *failoverConn) connectionLost(lost Conn, reason error) {
+	fc.mu.Lock()
+	if fc.closed || lost != fc.cur {
+		fc.mu.Unlock()
+		return
+	}
+	fc.failingOver = true
+	fc.setStatus(Reconnecting)
+	start := fc.idx
+	fc.mu.Unlock()
+
+	from := fc.targets[start]
+	lastErr := reason
+	for i := 1; i <= len(fc.targets); i++ {
+		idx := (start + i) % len(fc.targets)
+		sc, gen, err := fc.connect(idx)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		fc.mu.Lock()
+		if fc.closed {
+			fc.mu.Unlock()
+			sc.Close()
+			return
+		}
+		fc.cur, fc.idx, fc.curGen = sc, idx, gen
+		fc.failingOver = false
+		fc.setStatus(Connected)
+		subs := make([]*failoverSub, 0, len(fc.subs))
+		for sub := range fc.subs {
+			subs = append(subs, sub)
+		}
+		fc.mu.Unlock()
+
+		for _, sub := range subs {
+			sub.resubscribe(sc)
+		}
+		if cb := fc.opts.FailoverCB; cb != nil {
+			cb(from, fc.targets[idx], reason)
+		}
+		return
+	}
+	fc.mu.Lock()
+	if !fc.closed {
+		fc.closed = true
+		close(fc.closeCh)
+	}
+	fc.failingOver = false
+	fc.setStatus(Closed)
+	fc.mu.Unlock()
+	if cb := fc.opts.ConnectionLostCB; cb != nil {
+		cb(fc, lastErr)
+	}
+}
+
+// current returns the current connection, or an error if the connection
+// is closed or a failover is in progress.
+func (fc *failoverConn) current() (Conn, error) {
+	fc.mu.RLock()
+	defer fc.mu.RUnlock()
+	if fc.closed || fc.failingOver {
+		return nil, ErrConnectionClosed
+	}
+	return fc.cur, nil
+}
+
+// Publish implements the Conn interface.
+func (fc *failoverConn) Publish(subject string, data []byte) error {
+	sc, err := fc.current()
+	if err != nil {
+		return err
+	}
+	return sc.Publish(subject, data)
+}
+
+// PublishAsync implements the Conn interface.
+func (fc *failoverConn) PublishAsync(subject string, data []byte, ah AckHandler) (string, error) {
+	sc, err := fc.current()
+	if err != nil {
+		return "", err
+	}
+	return sc.PublishAsync(subject, data, ah)
+}
+
+// PublishWithReply implements the Conn interface.
+func (fc *failoverConn) PublishWithReply(subject, reply string, data []byte) error {
+	sc, err := fc.current()
+	if err != nil {
+		return err
+	}
+	return sc.PublishWithReply(subject, reply, data)
+}
+
+// PublishMsg implements the Conn interface.
+func (fc *failoverConn) PublishMsg(msg *OutMsg) error {
+	sc, err := fc.current()
+	if err != nil {
+		return err
+	}
+	return sc.PublishMsg(msg)
+}
+
+// Request implements the Conn interface.
+func (fc *failoverConn) Request(subject string, data []byte, timeout time.Duration) (*nats.Msg, error) {
+	sc, err := fc.current()
+	if err != nil {
+		return nil, err
+	}
+	return sc.Request(subject, data, timeout)
+}
+
+// Subscribe implements the Conn interface.
+func (fc *failoverConn) Subscribe(subject string, cb MsgHandler, opts ...SubscriptionOption) (Subscription, error) {
+	return fc.subscribe(func(sc Conn, opts ...SubscriptionOption) (Subscription, error) {
+		return sc.Subscribe(subject, cb, opts...)
+	}, opts)
+}
+
+// QueueSubscribe implements the Conn interface.
+func (fc *failoverConn) QueueSubscribe(subject, qgroup string, cb MsgHandler, opts ...SubscriptionOption) (Subscription, error) {
+	return fc.subscribe(func(sc Conn, opts ...SubscriptionOption) (Subscription, error) {
+		return sc.QueueSubscribe(subject, qgroup, cb, opts...)
+	}, opts)
+}
+
+// SubscribeE implements the Conn interface.
+func (fc *failoverConn) SubscribeE(subject string, cb MsgHandlerE, opts ...SubscriptionOption) (Subscription, error) {
+	return fc.subscribe(func(sc Conn, opts ...SubscriptionOption) (Subscription, error) {
+		return sc.SubscribeE(subject, cb, opts...)
+	}, opts)
+}
+
+// QueueSubscribeE implements the Conn interface.
+func (fc *failoverConn) QueueSubscribeE(subject, qgroup string, cb MsgHandlerE, opts ...SubscriptionOption) (Subscription, error) {
+	return fc.subscribe(func(sc Conn, opts ...SubscriptionOption) (Subscription, error) {
+		return sc.QueueSubscribeE(subject, qgroup, cb, opts...)
+	}, opts)
+}
+
+// ChanSubscribe implements the Conn interface.
+func (fc *failoverConn) ChanSubscribe(subject string, ch chan *Msg, opts ...SubscriptionOption) (Subscription, error) {
+	return fc.subscribe(func(sc Conn, opts ...SubscriptionOption) (Subscription, error) {
+		return sc.ChanSubscribe(subject, ch, opts...)
+	}, opts)
+}
+
+// ChanQueueSubscribe implements the Conn interface.
+func (fc *failoverConn) ChanQueueSubscribe(subject, qgroup string, ch chan *Msg, opts ...SubscriptionOption) (Subscription, error) {
+	return fc.subscribe(func(sc Conn, opts ...SubscriptionOption) (Subscription, error) {
+		return sc.ChanQueueSubscribe(subject, qgroup, ch, opts...)
+	}, opts)
+}
+
+// subscribe creates the subscription on the current connection and
+// registers it so that it is re-established on failover.
+func (fc *failoverConn) subscribe(create subscribeFunc, opts []SubscriptionOption) (Subscription, error) {
+	var so SubscriptionOptions
+	for _, opt := range opts {
+		if err := opt(&so); err != nil {
+			return nil, err
+		}
+	}
+	// Check the failover options now rather than when failing over.
+	failover := so.FailoverOptions
+	for _, opt := range failover {
+		if err := opt(&so); err != nil {
+			return nil, err
+		}
+	}
+	sc, err := fc.current()
+	if err != nil {
+		return nil, err
+	}
+	inner, err := create(sc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	sub := &failoverSub{
+		fc:       fc,
+		create:   create,
+		opts:     append([]SubscriptionOption(nil), opts...),
+		failover: failover,
+		cur:      inner,
+		closeCh:  make(chan struct{}),
+	}
+	fc.mu.Lock()
+	fc.subs[sub] = struct{}{}
+	fc.mu.Unlock()
+	return sub, nil
+}
+
+// Close implements the Conn interface.
+func (fc *failoverConn) Close() error {
+	fc.mu.Lock()
+	if fc.closed {
+		fc.mu.Unlock()
+		return nil
+	}
+	fc.closed = true
+	close(fc.closeCh)
+	fc.setStatus(Closed)
+	sc := fc.cur
+	fc.mu.Unlock()
+	return sc.Close()
+}
+
+// NatsConn implements the Conn interface.
+func (fc *failoverConn) NatsConn() *nats.Conn {
+	sc, err := fc.current()
+	if err != nil {
+		return nil
+	}
+	return sc.NatsConn()
+}
+
+// Status implements the Conn interface.
+func (fc *failoverConn) Status() Status {
+	fc.mu.RLock()
+	defer fc.mu.RUnlock()
+	if fc.closed {
+		return Closed
+	}
+	if fc.failingOver {
+		return Reconnecting
+	}
+	return fc.cur.Status()
+}
+
+// PingStats implements the Conn interface.
+func (fc *failoverConn) PingStats() PingStats {
+	fc.mu.RLock()
+	sc := fc.cur
+	fc.mu.RUnlock()
+	return sc.PingStats()
+}
+
+// Ping implements the Conn interface.
+func (fc *failoverConn) Ping(ctx context.Context) (time.Duration, error) {
+	sc, err := fc.current()
+	if err != nil {
+		return 0, err
+	}
+	return sc.Ping(ctx)
+}
+
+// PendingPubAcks implements the Conn interface.
+func (fc *failoverConn) PendingPubAcks() int {
+	fc.mu.RLock()
+	sc := fc.cur
+	fc.mu.RUnlock()
+	return sc.PendingPubAcks()
+}
+
+// ClientID implements the Conn interface.
+func (fc *failoverConn) ClientID() string {
+	fc.mu.RLock()
+	sc := fc.cur
+	fc.mu.RUnlock()
+	return sc.ClientID()
+}
+
+// ServerInfo implements the Conn interface. It returns the information of
+// the server of the cluster currently in use.
+func (fc *failoverConn) ServerInfo() ServerInfo {
+	fc.mu.RLock()
+	sc := fc.cur
+	fc.mu.RUnlock()
+	return sc.ServerInfo()
+}
+
+// subscribeFunc creates a subscription of a given type on the given connection.
+type subscribeFunc func(sc Conn, opts ...SubscriptionOption) (Subscription, error)
+
+// failoverSub is a Subscription that is re-established on failover.
+type failoverSub struct {
+	mu       sync.RWMutex
+	fc       *failoverConn
+	create   subscribeFunc
+	opts     []SubscriptionOption
+	failover []SubscriptionOption
+	cur      Subscription // nil if the subscription could not be re-established.
+	closed   bool
+	closeCh  chan struct{} // Closed when the subscription is closed.
+	paused   bool          // Applied to the subscription when re-established.
+}
+
+// resubscribe re-establishes the subscription on the given connection,
+// retrying in the background if that fails.
+func (sub *failoverSub) resubscribe(sc Conn) {
+	if err := sub.tryResubscribe(sc); err != nil {
+		go sub.retryResubscribe(sc, err)
+	}
+}
+
+// tryResubscribe re-establishes the subscription on the given connection.
+// Returns nil if it succeeded or if the subscription has been closed. A
+// subscription that the library closed on the previous cluster (for instance
+// when it reached its stop position) is closed and not re-established.
+func (sub *failoverSub) tryResubscribe(sc Conn) error {
+	sub.mu.Lock()
+	if !sub.closed && sub.cur != nil && isClosedSubscription(sub.cur) {
+		sub.closed = true
+		close(sub.closeCh)
+		sub.mu.Unlock()
+		sub.fc.mu.Lock()
+		delete(sub.fc.subs, sub)
+		sub.fc.mu.Unlock()
+		return nil
+	}
+	closed := sub.closed
+	opts := append(append([]SubscriptionOption(nil), sub.opts...), sub.failover...)
+	sub.mu.Unlock()
+	if closed {
+		return nil
+	}
+	inner, err := sub.create(sc, opts...)
+	sub.mu.Lock()
+	if sub.closed {
+		sub.mu.Unlock()
+		if inner != nil {
+			inner.Close()
+		}
+		return nil
+	}
+	// On error, do not keep the subscription of the previous cluster.
+	sub.cur = inner
+	if inner != nil && sub.paused {
+		inner.Pause()
+	}
+	sub.mu.Unlock()
+	return err
+}
+
+// retryResubscribe notifies the ResubscribeErrorHandler of the error and
+// retries to re-establish the subscription until it succeeds, or the
+// subscription or connection is closed, or another failover happened (which
+// re-establishes the subscription itself).
+func (sub *failoverSub) retryResubscribe(sc Conn, err error) {
+	fc := sub.fc
+	wait := failoverResubscribeMinWait
+	for {
+		// Options are immutable.
+		if cb := fc.opts.ResubscribeErrorCB; cb != nil {
+			cb(sub, err)
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-t.C:
+		case <-fc.closeCh:
+			t.Stop()
+			return
+		case <-sub.closeCh:
+			t.Stop()
+			return
+		}
+		fc.mu.RLock()
+		stale := fc.cur != sc || fc.failingOver
+		fc.mu.RUnlock()
+		if stale {
+			return
+		}
+		if err = sub.tryResubscribe(sc); err == nil {
+			return
+		}
+		if wait *= 2; wait > failoverResubscribeMaxWait {
+			wait = failoverResubscribeMaxWait
+		}
+	}
+}
+
+// isClosedSubscription returns true if the subscription was closed, as
+// opposed to a subscription of a connection that was lost.
+func isClosedSubscription(s Subscription) bool {
+	sub, ok := s.(*subscription)
+	if !ok {
+		return false
+	}
+	sub.RLock()
+	defer sub.RUnlock()
+	return sub.closed
+}
+
+// current returns the subscription on the current cluster.
+func (sub *failoverSub) current() (Subscription, error) {
+	sub.mu.RLock()
+	defer sub.mu.RUnlock()
+	if sub.closed {
+		return nil, ErrBadSubscription
+	}
+	if sub.cur == nil {
+		return nil, ErrNotSubscribedYet
+	}
+	return sub.cur, nil
+}
+
+// closeOrUnsubscribe closes or unsubscribes the current subscription and
+// stops re-establishing it.
+func (sub *failoverSub) closeOrUnsubscribe(doClose bool) error {
+	sub.mu.Lock()
+	if sub.closed {
+		sub.mu.Unlock()
+		return ErrBadSubscription
+	}
+	sub.closed = true
+	close(sub.closeCh)
+	inner := sub.cur
+	sub.mu.Unlock()
+
+	sub.fc.mu.Lock()
+	delete(sub.fc.subs, sub)
+	sub.fc.mu.Unlock()
+
+	if inner == nil {
+		return nil
+	}
+	if doClose {
+		return inner.Close()
+	}
+	return inner.Unsubscribe()
+}
+
+// Unsubscribe implements the Subscription interface.
+func (sub *failoverSub) Unsubscribe() error {
+	return sub.closeOrUnsubscribe(false)
+}
+
+// Close implements the Subscription interface.
+func (sub *failoverSub) Close() error {
+	return sub.closeOrUnsubscribe(true)
+}
+
+// ClearMaxPending implements the Subscription interface.
+func (sub *failoverSub) ClearMaxPending() error {
+	s, err := sub.current()
+	if err != nil {
+		return err
+	}
+	return s.ClearMaxPending()
+}
+
+// Delivered implements the Subscription interface.
+func (sub *failoverSub) Delivered() (int64, error) {
+	s, err := sub.current()
+	if err != nil {
+		return -1, err
+	}
+	return s.Delivered()
+}
+
+// Dropped implements the Subscription interface.
+func (sub *failoverSub) Dropped() (int, error) {
+	s, err := sub.current()
+	if err != nil {
+		return -1, err
+	}
+	return s.Dropped()
+}
+
+// IsValid implements the Subscription interface.
+func (sub *failoverSub) IsValid() bool {
+	s, err := sub.current()
+	if err != nil {
+		return false
+	}
+	return s.IsValid()
+}
+
+// MaxPending implements the Subscription interface.
+func (sub *failoverSub) MaxPending() (int, int, error) {
+	s, err := sub.current()
+	if err != nil {
+		return -1, -1, err
+	}
+	return s.MaxPending()
+}
+
+// Pending implements the Subscription interface.
+func (sub *failoverSub) Pending() (int, int, error) {
+	s, err := sub.current()
+	if err != nil {
+		return -1, -1, err
+	}
+	return s.Pending()
+}
+
+// PendingLimits implements the Subscription interface.
+func (sub *failoverSub) PendingLimits() (int, int, error) {
+	s, err := sub.current()
+	if err != nil {
+		return -1, -1, err
+	}
+	return s.PendingLimits()
+}
+
+// SetPendingLimits implements the Subscription interface.
+func (sub *failoverSub) SetPendingLimits(msgLimit, bytesLimit int) error {
+	s, err := sub.current()
+	if err != nil {
+		return err
+	}
+	return s.SetPendingLimits(msgLimit, bytesLimit)
+}
+
+// Stats implements the Subscription interface. Statistics are those of the
+// subscription on the current cluster.
+func (sub *failoverSub) Stats() SubscriptionStats {
+	s, err := sub.current()
+	if err != nil {
+		return SubscriptionStats{}
+	}
+	return s.Stats()
+}
+
+// Subject implements the Subscription interface.
+func (sub *failoverSub) Subject() string {
+	s, err := sub.current()
+	if err != nil {
+		return ""
+	}
+	return s.Subject()
+}
+
+// QueueGroup implements the Subscription interface.
+func (sub *failoverSub) QueueGroup() string {
+	s, err := sub.current()
+	if err != nil {
+		return ""
+	}
+	return s.QueueGroup()
+}
+
+// DurableName implements the Subscription interface.
+func (sub *failoverSub) DurableName() string {
+	s, err := sub.current()
+	if err != nil {
+		return ""
+	}
+	return s.DurableName()
+}
+
+// Options implements the Subscription interface. Options are those of the
+// subscription on the current cluster, which include the FailoverOptions.
+func (sub *failoverSub) Options() SubscriptionOptions {
+	s, err := sub.current()
+	if err != nil {
+		return SubscriptionOptions{}
+	}
+	return s.Options()
+}
+
+// Inbox implements the Subscription interface.
+func (sub *failoverSub) Inbox() string {
+	s, err := sub.current()
+	if err != nil {
+		return ""
+	}
+	return s.Inbox()
+}
+
+// AckInbox implements the Subscription interface.
+func (sub *failoverSub) AckInbox() string {
+	s, err := sub.current()
+	if err != nil {
+		return ""
+	}
+	return s.AckInbox()
+}
+
+// Pause implements the Subscription interface. The subscription remains
+// paused when re-established on another cluster.
+func (sub *failoverSub) Pause() error {
+	return sub.setPaused(true)
+}
+
+// Resume implements the Subscription interface.
+func (sub *failoverSub) Resume() error {
+	return sub.setPaused(false)
+}
+
+func (sub *failoverSub) setPaused(paused bool) error {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	if sub.closed {
+		return ErrBadSubscription
+	}
+	sub.paused = paused
+	if sub.cur == nil {
+		return nil
+	}
+	if paused {
+		return sub.cur.Pause()
+	}
+	return sub.cur.Resume()
+}
+
+// Update implements the Subscription interface. The updated options are also
+// applied when the subscription is re-established on another cluster.
+func (sub *failoverSub) Update(opts ...SubscriptionOption) error {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	if sub.closed {
+		return ErrBadSubscription
+	}
+	if sub.cur == nil {
+		return ErrNotSubscribedYet
+	}
+	if err := sub.cur.Update(opts...); err != nil {
+		return err
+	}
+	sub.opts = append(append([]SubscriptionOption(nil), sub.opts...), opts...)
+	return nil
+}
@@ -0,0 +1,256 @@
+// Copyright 2026 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package health provides an HTTP handler reporting the health and readiness
+// of a NATS Streaming connection and its subscriptions.
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	stan "github.com/nats-io/stan.go"
+)
+
+// Options are the thresholds used to decide if a connection is ready.
+// A zero value means that the corresponding check is disabled.
+type Options struct {
+	// MaxMissedPings is the number of consecutive PINGs without a response
+	// above which the connection is not ready. Note that when PINGs are
+	// missing, this threshold is checked instead of the connection status,
+	// so with the zero value, missing PINGs do not affect readiness.
+	MaxMissedPings int
+
+	// MaxPingRTT is the last PING round-trip time above which the
+	// connection is not ready.
+	MaxPingRTT time.Duration
+
+	// MaxPendingPubAcks is the number of published messages waiting for
+	// an ACK above which the connection is not ready.
+	MaxPendingPubAcks int
+
+	// MaxMessageAge is the time since the last message received by a
+	// subscription above which the connection is not ready. Subscriptions
+	// that have not received any message are not checked.
+	MaxMessageAge time.Duration
+}
+
+// Option is a function on the options of a Handler.
+type Option func(*Options)
+
+// MaxMissedPings is an Option to set the number of consecutive missed PINGs
+// above which the connection is not ready.
+func MaxMissedPings(n int) Option {
+	return func(o *Options) {
+		o.MaxMissedPings = n
+	}
+}
+
+// MaxPingRTT is an Option to set the PING round-trip time above which the
+// connection is not ready.
+func MaxPingRTT(d time.Duration) Option {
+	return func(o *Options) {
+		o.MaxPingRTT = d
+	}
+}
+
+// MaxPendingPubAcks is an Option to set the number of published messages
+// waiting for an ACK above which the connection is not ready.
+func MaxPendingPubAcks(n int) Option {
+	return func(o *Options) {
+		o.MaxPendingPubAcks = n
+	}
+}
+
+// MaxMessageAge is an Option to set the time since the last message received
+// by a subscription above which the connection is not ready.
+func MaxMessageAge(d time.Duration) Option {
+	return func(o *Options) {
+		o.MaxMessageAge = d
+	}
+}
+
+// Handler is an http.Handler that reports, as JSON, the status of a
+// streaming connection and of the subscriptions registered with
+// AddSubscription. It responds with http.StatusOK if the connection is
+// ready and http.StatusServiceUnavailable otherwise.
+// The handler is safe to use in multiple Go routines concurrently.
+type Handler struct {
+	sc   stan.Conn
+	opts Options
+
+	mu   sync.RWMutex
+	subs map[string]stan.Subscription
+}
+
+// Report is the JSON document produced by the Handler.
+type Report struct {
+	// Status is the status of the connection.
+	Status string `json:"status"`
+	// Live is false only if the connection is closed.
+	Live bool `json:"live"`
+	// Ready is true if the connection is live and all checks passed.
+	Ready bool `json:"ready"`
+	// Reasons lists the checks that failed.
+	Reasons []string `json:"reasons,omitempty"`
+	// Ping reports the statistics of the PINGs sent to the server.
+	Ping PingReport `json:"ping"`
+	// PendingPubAcks is the number of published messages waiting for an ACK.
+	PendingPubAcks int `json:"pending_pub_acks"`
+	// Subscriptions reports on each registered subscription.
+	Subscriptions []SubscriptionReport `json:"subscriptions,omitempty"`
+}
+
+// PingReport is the part of the Report about PINGs.
+type PingReport struct {
+	LastRTT string `json:"last_rtt"`
+	AvgRTT  string `json:"avg_rtt"`
+	Missed  int    `json:"missed"`
+}
+
+// SubscriptionReport is the part of the Report about a subscription.
+type SubscriptionReport struct {
+	Name     string `json:"name"`
+	Valid    bool   `json:"valid"`
+	Received uint64 `json:"received"`
+	// LastSequence and LastAckedSequence help diagnosing a stuck durable.
+	LastSequence      uint64 `json:"last_sequence"`
+	LastAckedSequence uint64 `json:"last_acked_sequence"`
+	Redelivered       uint64 `json:"redelivered"`
+	// LastMessageAge is the time since the last message was received,
+	// empty if none was received.
+	LastMessageAge string `json:"last_message_age,omitempty"`
+}
+
+// NewHandler returns a Handler for the given connection.
+func NewHandler(sc stan.Conn, options ...Option) *Handler {
+	h := &Handler{sc: sc, subs: make(map[string]stan.Subscription)}
+	for _, opt := range options {
+		opt(&h.opts)
+	}
+	return h
+}
+
+// AddSubscription registers a subscription under the given name so that
+// its liveness and last message age are reported. A subscription that is
+// no longer valid makes the connection not ready, so it should be removed
+// with RemoveSubscription if it is closed on purpose.
+func (h *Handler) AddSubscription(name string, sub stan.Subscription) {
+	h.mu.Lock()
+	h.subs[name] = sub
+	h.mu.Unlock()
+}
+
+// RemoveSubscription removes the subscription registered under the given name.
+func (h *Handler) RemoveSubscription(name string) {
+	h.mu.Lock()
+	delete(h.subs, name)
+	h.mu.Unlock()
+}
+
+// Check runs all checks and returns the resulting report.
+func (h *Handler) Check() *Report {
+	st := h.sc.Status()
+	ps := h.sc.PingStats()
+	r := &Report{
+		Status: st.String(),
+		Live:   st != stan.Closed,
+		Ping: PingReport{
+			LastRTT: ps.LastRTT.String(),
+			AvgRTT:  ps.AvgRTT.String(),
+			Missed:  ps.Missed,
+		},
+		PendingPubAcks: h.sc.PendingPubAcks(),
+	}
+	switch st {
+	case stan.Connected:
+	case stan.PingsMissing:
+		if max := h.opts.MaxMissedPings; max > 0 && ps.Missed > max {
+			r.Reasons = append(r.Reasons, fmt.Sprintf("%v missed pings (max=%v)", ps.Missed, h.opts.MaxMissedPings))
+		}
+	default:
+		r.Reasons = append(r.Reasons, fmt.Sprintf("connection status is %v", st))
+	}
+	if max := h.opts.MaxPingRTT; max > 0 && ps.LastRTT > max {
+		r.Reasons = append(r.Reasons, fmt.Sprintf("ping RTT is %v (max=%v)", ps.LastRTT, max))
+	}
+	if max := h.opts.MaxPendingPubAcks; max > 0 && r.PendingPubAcks > max {
+		r.Reasons = append(r.Reasons, fmt.Sprintf("%v pending pub acks (max=%v)", r.PendingPubAcks, max))
+	}
+
+	h.mu.RLock()
+	names := make([]string, 0, len(h.subs))
+	for name := range h.subs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	now := time.Now()
+	for _, name := range names {
+		sub := h.subs[name]
+		stats := sub.Stats()
+		sr := SubscriptionReport{
+			Name:              name,
+			Valid:             sub.IsValid(),
+			Received:          stats.Received,
+			LastSequence:      stats.LastSequence,
+			LastAckedSequence: stats.LastAckedSequence,
+			Redelivered:       stats.Redelivered,
+		}
+		if !sr.Valid {
+			r.Reasons = append(r.Reasons, fmt.Sprintf("subscription %q is not valid", name))
+		}
+		if !stats.LastReceived.IsZero() {
+			age := now.Sub(stats.LastReceived)
+			sr.LastMessageAge = age.String()
+			if max := h.opts.MaxMessageAge; max > 0 && age > max {
+				r.Reasons = append(r.Reasons, fmt.Sprintf("subscription %q last message age is %v (max=%v)", name, age, max))
+			}
+		}
+		r.Subscriptions = append(r.Subscriptions, sr)
+	}
+	h.mu.RUnlock()
+
+	r.Ready = r.Live && len(r.Reasons) == 0
+	return r
+}
+
+// ServeHTTP implements http.Handler and reports readiness: it responds with
+// http.StatusServiceUnavailable if any check failed.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r := h.Check()
+	writeReport(w, r, r.Ready)
+}
+
+// Liveness returns an http.Handler that reports liveness: it responds with
+// http.StatusServiceUnavailable only if the connection is closed. The body is
+// the same report as the one produced by the Handler.
+func (h *Handler) Liveness() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r := h.Check()
+		writeReport(w, r, r.Live)
+	})
+}
+
+func writeReport(w http.ResponseWriter, r *Report, ok bool) {
+	w.Header().Set("Content-Type", "application/json")
+	if ok {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(r)
+}
@@ -0,0 +1,158 @@
+// Copyright 2026 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-streaming-server/server"
+	stan "github.com/nats-io/stan.go"
+)
+
+const (
+	clusterName = "my_test_cluster"
+	clientName  = "me"
+)
+
+func runServer(t *testing.T) *server.StanServer {
+	t.Helper()
+	s, err := server.RunServer(clusterName)
+	if err != nil {
+		t.Fatalf("Error starting server: %v", err)
+	}
+	return s
+}
+
+func getReport(t *testing.T, h http.Handler, expectedCode int) *Report {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+	if rec.Code != expectedCode {
+		t.Fatalf("Expected code %v, got %v: %s", expectedCode, rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("Unexpected content type: %q", ct)
+	}
+	r := &Report{}
+	if err := json.Unmarshal(rec.Body.Bytes(), r); err != nil {
+		t.Fatalf("Error decoding report: %v", err)
+	}
+	return r
+}
+
+func TestHandler(t *testing.T) {
+	s := runServer(t)
+	defer s.Shutdown()
+
+	sc, err := stan.Connect(clusterName, clientName)
+	if err != nil {
+		t.Fatalf("Error on connect: %v", err)
+	}
+	defer sc.Close()
+
+	ch := make(chan bool, 1)
+	sub, err := sc.Subscribe("foo", func(*stan.Msg) { ch <- true })
+	if err != nil {
+		t.Fatalf("Error on subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	h := NewHandler(sc, MaxMessageAge(50*time.Millisecond), MaxPendingPubAcks(100))
+	h.AddSubscription("foo", sub)
+
+	r := getReport(t, h, http.StatusOK)
+	if !r.Live || !r.Ready || r.Status != "CONNECTED" || len(r.Subscriptions) != 1 {
+		t.Fatalf("Unexpected report: %+v", r)
+	}
+	if sr := r.Subscriptions[0]; sr.Name != "foo" || !sr.Valid || sr.Received != 0 || sr.LastMessageAge != "" {
+		t.Fatalf("Unexpected subscription report: %+v", sr)
+	}
+
+	if err := sc.Publish("foo", []byte("hello")); err != nil {
+		t.Fatalf("Error on publish: %v", err)
+	}
+	select {
+	case <-ch:
+	case <-time.After(time.Second):
+		t.Fatal("Did not get our message")
+	}
+	r = getReport(t, h, http.StatusOK)
+	if sr := r.Subscriptions[0]; sr.Received != 1 || sr.LastSequence != 1 || sr.LastMessageAge == "" {
+		t.Fatalf("Unexpected subscription report: %+v", sr)
+	}
+
+	// The last message is now too old.
+	time.Sleep(100 * time.Millisecond)
+	r = getReport(t, h, http.StatusServiceUnavailable)
+	if r.Ready || !r.Live || len(r.Reasons) != 1 || !strings.Contains(r.Reasons[0], "last message age") {
+		t.Fatalf("Unexpected report: %+v", r)
+	}
+	// Still live though.
+	getReport(t, h.Liveness(), http.StatusOK)
+
+	// An invalid subscription makes the connection not ready.
+	h = NewHandler(sc)
+	h.AddSubscription("foo", sub)
+	sub.Unsubscribe()
+	r = getReport(t, h, http.StatusServiceUnavailable)
+	if len(r.Reasons) != 1 || !strings.Contains(r.Reasons[0], `subscription "foo" is not valid`) {
+		t.Fatalf("Unexpected report: %+v", r)
+	}
+	h.RemoveSubscription("foo")
+	getReport(t, h, http.StatusOK)
+
+	sc.Close()
+	r = getReport(t, h.Liveness(), http.StatusServiceUnavailable)
+	if r.Live || r.Ready || r.Status != "CLOSED" {
+		t.Fatalf("Unexpected report: %+v", r)
+	}
+}
+
+// pingsMissingConn reports missing PINGs.
+type pingsMissingConn struct {
+	stan.Conn
+	missed int
+}
+
+func (c *pingsMissingConn) Status() stan.Status { return stan.PingsMissing }
+
+func (c *pingsMissingConn) PingStats() stan.PingStats { return stan.PingStats{Missed: c.missed} }
+
+func TestHandlerMissedPings(t *testing.T) {
+	s := runServer(t)
+	defer s.Shutdown()
+
+	sc, err := stan.Connect(clusterName, clientName)
+	if err != nil {
+		t.Fatalf("Error on connect: %v", err)
+	}
+	defer sc.Close()
+	pmc := &pingsMissingConn{Conn: sc, missed: 2}
+
+	// The check is disabled by default.
+	r := getReport(t, NewHandler(pmc), http.StatusOK)
+	if !r.Ready || r.Status != stan.PingsMissing.String() || r.Ping.Missed != 2 {
+		t.Fatalf("Unexpected report: %+v", r)
+	}
+	getReport(t, NewHandler(pmc, MaxMissedPings(2)), http.StatusOK)
+	r = getReport(t, NewHandler(pmc, MaxMissedPings(1)), http.StatusServiceUnavailable)
+	if len(r.Reasons) != 1 || !strings.Contains(r.Reasons[0], "2 missed pings") {
+		t.Fatalf("Unexpected report: %+v", r)
+	}
+}
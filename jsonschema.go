@@ -0,0 +1,258 @@
+// Copyright 2026 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stan
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+// jsonSchema is a compiled schema of the JSON Schema subset supported by
+// FileSchemaRegistry.
+type jsonSchema struct {
+	types      []string
+	enum       []interface{}
+	properties map[string]*jsonSchema
+	required   []string
+	// additional is the schema of properties not listed in properties.
+	// If nil, they are allowed unless noAdditional is set.
+	additional   *jsonSchema
+	noAdditional bool
+	items        *jsonSchema
+	minItems     int
+	maxItems     int // -1 if not set
+	minLength    int
+	maxLength    int // -1 if not set
+	pattern      *regexp.Regexp
+	minimum      *float64
+	maximum      *float64
+	exclusiveMin *float64
+	exclusiveMax *float64
+}
+
+var jsonSchemaTypes = map[string]bool{
+	"object": true, "array": true, "string": true, "number": true,
+	"integer": true, "boolean": true, "null": true,
+}
+
+// compileJSONSchema parses a schema. An empty schema accepts any value.
+func compileJSONSchema(raw json.RawMessage) (*jsonSchema, error) {
+	s := &jsonSchema{maxItems: -1, maxLength: -1}
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return s, nil
+	}
+	var kw map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &kw); err != nil {
+		return nil, err
+	}
+	for name, v := range kw {
+		var err error
+		switch name {
+		case "$schema", "$id", "$comment", "title", "description", "default", "examples":
+			// Annotations.
+		case "type":
+			var t string
+			if err = json.Unmarshal(v, &t); err == nil {
+				s.types = []string{t}
+			} else {
+				err = json.Unmarshal(v, &s.types)
+			}
+			for _, t := range s.types {
+				if !jsonSchemaTypes[t] {
+					err = fmt.Errorf("unknown type %q", t)
+				}
+			}
+		case "enum":
+			err = json.Unmarshal(v, &s.enum)
+		case "const":
+			var c interface{}
+			err = json.Unmarshal(v, &c)
+			s.enum = []interface{}{c}
+		case "properties":
+			var props map[string]json.RawMessage
+			if err = json.Unmarshal(v, &props); err == nil {
+				s.properties = make(map[string]*jsonSchema, len(props))
+				for p, ps := range props {
+					if s.properties[p], err = compileJSONSchema(ps); err != nil {
+						break
+					}
+				}
+			}
+		case "required":
+			err = json.Unmarshal(v, &s.required)
+		case "additionalProperties":
+			var allowed bool
+			if json.Unmarshal(v, &allowed) == nil {
+				s.noAdditional = !allowed
+			} else {
+				s.additional, err = compileJSONSchema(v)
+			}
+		case "items":
+			s.items, err = compileJSONSchema(v)
+		case "minItems":
+			err = json.Unmarshal(v, &s.minItems)
+		case "maxItems":
+			err = json.Unmarshal(v, &s.maxItems)
+		case "minLength":
+			err = json.Unmarshal(v, &s.minLength)
+		case "maxLength":
+			err = json.Unmarshal(v, &s.maxLength)
+		case "pattern":
+			var p string
+			if err = json.Unmarshal(v, &p); err == nil {
+				s.pattern, err = regexp.Compile(p)
+			}
+		case "minimum":
+			err = json.Unmarshal(v, &s.minimum)
+		case "maximum":
+			err = json.Unmarshal(v, &s.maximum)
+		case "exclusiveMinimum":
+			err = json.Unmarshal(v, &s.exclusiveMin)
+		case "exclusiveMaximum":
+			err = json.Unmarshal(v, &s.exclusiveMax)
+		default:
+			err = fmt.Errorf("unsupported keyword")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%q: %v", name, err)
+		}
+	}
+	return s, nil
+}
+
+// validateJSON returns an error wrapping ErrInvalidPayload if the data is
+// not a JSON document matching the schema.
+func (s *jsonSchema) validateJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	if reason := s.validate(v); reason != "" {
+		return fmt.Errorf("%w: %s", ErrInvalidPayload, reason)
+	}
+	return nil
+}
+
+// validate returns why the value does not match the schema, or an empty
+// string if it does.
+func (s *jsonSchema) validate(v interface{}) string {
+	return s.validateAt("$", v)
+}
+
+func (s *jsonSchema) validateAt(path string, v interface{}) string {
+	if len(s.types) > 0 && !s.hasType(v) {
+		return fmt.Sprintf("%s: expected %v, got %s", path, s.types, jsonType(v))
+	}
+	if s.enum != nil {
+		found := false
+		for _, e := range s.enum {
+			if reflect.DeepEqual(e, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Sprintf("%s: value not allowed", path)
+		}
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for _, p := range s.required {
+			if _, ok := v[p]; !ok {
+				return fmt.Sprintf("%s: missing property %q", path, p)
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			ps := s.properties[k]
+			if ps == nil {
+				if s.noAdditional {
+					return fmt.Sprintf("%s: unexpected property %q", path, k)
+				}
+				ps = s.additional
+			}
+			if ps != nil {
+				if r := ps.validateAt(path+"."+k, v[k]); r != "" {
+					return r
+				}
+			}
+		}
+	case []interface{}:
+		if len(v) < s.minItems || (s.maxItems >= 0 && len(v) > s.maxItems) {
+			return fmt.Sprintf("%s: invalid number of items: %d", path, len(v))
+		}
+		if s.items != nil {
+			for i, item := range v {
+				if r := s.items.validateAt(path+"["+strconv.Itoa(i)+"]", item); r != "" {
+					return r
+				}
+			}
+		}
+	case string:
+		n := utf8.RuneCountInString(v)
+		if n < s.minLength || (s.maxLength >= 0 && n > s.maxLength) {
+			return fmt.Sprintf("%s: invalid length: %d", path, n)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			return fmt.Sprintf("%s: does not match pattern %q", path, s.pattern)
+		}
+	case float64:
+		if (s.minimum != nil && v < *s.minimum) || (s.maximum != nil && v > *s.maximum) ||
+			(s.exclusiveMin != nil && v <= *s.exclusiveMin) || (s.exclusiveMax != nil && v >= *s.exclusiveMax) {
+			return fmt.Sprintf("%s: %v out of range", path, v)
+		}
+	}
+	return ""
+}
+
+func (s *jsonSchema) hasType(v interface{}) bool {
+	t := jsonType(v)
+	for _, want := range s.types {
+		if want == t || (want == "number" && t == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonType returns the JSON Schema type of a decoded JSON value.
+func jsonType(v interface{}) string {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case bool:
+		return "boolean"
+	}
+	return "null"
+}
@@ -0,0 +1,125 @@
+// Copyright 2026 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stan
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// Middleware wraps a MsgHandler to add behavior such as logging, metrics,
+// panic recovery or tracing. Middlewares are invoked before the library
+// decides whether to acknowledge the message in auto-ack mode.
+type Middleware func(MsgHandler) MsgHandler
+
+// UseMiddleware is an Option to set middlewares applied to the handlers of
+// all subscriptions created with this connection. The first middleware is
+// the outermost one. Middlewares set at the connection level wrap the ones
+// set with the SubscriptionMiddleware option.
+func UseMiddleware(mw ...Middleware) Option {
+	return func(o *Options) error {
+		o.Middlewares = append([]Middleware(nil), mw...)
+		return nil
+	}
+}
+
+// SubscriptionMiddleware is an Option to set middlewares applied to the
+// handler of this subscription. The first middleware is the outermost one.
+func SubscriptionMiddleware(mw ...Middleware) SubscriptionOption {
+	return func(o *SubscriptionOptions) error {
+		o.Middlewares = append([]Middleware(nil), mw...)
+		return nil
+	}
+}
+
+// chainMiddlewares wraps the handler with the given lists of middlewares,
+// the first middleware of the first list being the outermost.
+func chainMiddlewares(h MsgHandler, lists ...[]Middleware) MsgHandler {
+	for i := len(lists) - 1; i >= 0; i-- {
+		mws := lists[i]
+		for j := len(mws) - 1; j >= 0; j-- {
+			h = mws[j](h)
+		}
+	}
+	return h
+}
+
+// Recover returns a Middleware that recovers from a panic in the handler
+// and invokes the given callback with the message and the recovered value.
+// If the callback is nil, the panic is printed on the standard error.
+// After a recovered panic, the message is processed as if the handler had
+// returned normally, which means that it is acknowledged in auto-ack mode.
+func Recover(cb func(msg *Msg, r interface{})) Middleware {
+	return func(next MsgHandler) MsgHandler {
+		return func(msg *Msg) {
+			defer func() {
+				if r := recover(); r != nil {
+					if cb != nil {
+						cb(msg, r)
+					} else {
+						fmt.Fprintf(os.Stderr, "stan: recovered from panic in handler for %q (seq=%v): %v\n",
+							msg.Subject, msg.Sequence, r)
+					}
+				}
+			}()
+			next(msg)
+		}
+	}
+}
+
+// Timeout returns a Middleware that invokes the callback (if not nil) when
+// the handler has not returned after the given duration. The handler is not
+// interrupted: it keeps running on the subscription's go routine, so that
+// messages are still processed one at a time and in order, and a panic is
+// handled as configured with RecoverPanics. In auto-ack mode, a message for
+// which the handler timed out is not acknowledged and will be redelivered by
+// the cluster after AckWait. In manual-ack mode, the handler may still
+// acknowledge it.
+func Timeout(d time.Duration, cb func(msg *Msg)) Middleware {
+	return func(next MsgHandler) MsgHandler {
+		return func(msg *Msg) {
+			t := time.AfterFunc(d, func() {
+				if cb != nil {
+					cb(msg)
+				}
+			})
+			defer func() {
+				// The timer has fired if it could not be stopped.
+				if !t.Stop() {
+					msg.setNoAck()
+				}
+			}()
+			next(msg)
+		}
+	}
+}
+
+// Logging returns a Middleware that logs, using the given function, every
+// message processed by the handler along with the time it took. If logf is
+// nil, the standard logger is used.
+func Logging(logf func(format string, args ...interface{})) Middleware {
+	if logf == nil {
+		logf = log.Printf
+	}
+	return func(next MsgHandler) MsgHandler {
+		return func(msg *Msg) {
+			start := time.Now()
+			next(msg)
+			logf("stan: processed message subject=%q seq=%v redelivered=%v in %v",
+				msg.Subject, msg.Sequence, msg.Redelivered, time.Since(start))
+		}
+	}
+}
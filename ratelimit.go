@@ -0,0 +1,240 @@
+// Copyright 2026 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stan
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimit is a rate limit enforced with token buckets.
+type RateLimit struct {
+	// MsgsPerSec is the maximum rate of messages. Zero means no limit.
+	MsgsPerSec float64
+	// MsgsBurst is the number of messages that can be sent at once after
+	// a period of inactivity. Defaults to MsgsPerSec, and at least 1.
+	MsgsBurst int
+	// BytesPerSec is the maximum rate of payload bytes. Zero means no limit.
+	BytesPerSec float64
+	// BytesBurst is the number of bytes that can be sent at once after
+	// a period of inactivity. Defaults to BytesPerSec, and at least 1.
+	// A message larger than the burst is sent once the bucket is full.
+	BytesBurst int
+}
+
+// RateLimitMode determines how PublishAsync behaves when the rate limit
+// is exceeded. Publish always blocks.
+type RateLimitMode int
+
+const (
+	// RateLimitBlock makes PublishAsync block until the message can be
+	// published. This is the default.
+	RateLimitBlock RateLimitMode = iota
+	// RateLimitReject makes PublishAsync return ErrRateLimited.
+	RateLimitReject
+)
+
+// PublishRateLimit is an Option to limit the rate at which messages are
+// published by this connection, across all subjects.
+func PublishRateLimit(limit RateLimit) Option {
+	return func(o *Options) error {
+		if err := limit.validate(); err != nil {
+			return err
+		}
+		o.PublishRateLimit = limit
+		return nil
+	}
+}
+
+// PublishRateLimitPerSubject is an Option to limit the rate at which messages
+// are published by this connection to each subject. This is enforced in
+// addition to the PublishRateLimit option, if set. Note that the state of the
+// limit is kept for every subject messages are published to.
+func PublishRateLimitPerSubject(limit RateLimit) Option {
+	return func(o *Options) error {
+		if err := limit.validate(); err != nil {
+			return err
+		}
+		o.PublishRateLimitPerSubject = limit
+		return nil
+	}
+}
+
+// PublishRateLimitMode is an Option to set how PublishAsync behaves when
+// the publish rate limit is exceeded.
+func PublishRateLimitMode(mode RateLimitMode) Option {
+	return func(o *Options) error {
+		o.PublishRateLimitMode = mode
+		return nil
+	}
+}
+
+func (l RateLimit) validate() error {
+	if l.MsgsPerSec < 0 || l.BytesPerSec < 0 || l.MsgsBurst < 0 || l.BytesBurst < 0 {
+		return fmt.Errorf("stan: invalid rate limit: %+v", l)
+	}
+	return nil
+}
+
+func (l RateLimit) isSet() bool {
+	return l.MsgsPerSec > 0 || l.BytesPerSec > 0
+}
+
+// tokenBucket holds tokens added at a given rate up to a burst. Tokens are
+// taken upfront, so the number of tokens can be negative, meaning that the
+// next takers have to wait for this debt to be paid.
+type tokenBucket struct {
+	rate   float64 // Tokens per second.
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	b := math.Max(float64(burst), 1)
+	if burst == 0 {
+		b = math.Max(rate, 1)
+	}
+	return &tokenBucket{rate: rate, burst: b, tokens: b, last: time.Now()}
+}
+
+// wait returns how long to wait before n tokens can be taken.
+func (b *tokenBucket) wait(now time.Time, n float64) time.Duration {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(b.burst, b.tokens+elapsed.Seconds()*b.rate)
+		b.last = now
+	}
+	// Do not require more than the burst so that large takes are possible.
+	need := math.Min(n, b.burst)
+	if b.tokens >= need {
+		return 0
+	}
+	return time.Duration((need - b.tokens) / b.rate * float64(time.Second))
+}
+
+// rateLimiter enforces a RateLimit. It is not safe for concurrent use.
+type rateLimiter struct {
+	msgs  *tokenBucket // nil if messages are not limited
+	bytes *tokenBucket // nil if bytes are not limited
+}
+
+func newRateLimiter(l RateLimit) *rateLimiter {
+	rl := &rateLimiter{}
+	if l.MsgsPerSec > 0 {
+		rl.msgs = newTokenBucket(l.MsgsPerSec, l.MsgsBurst)
+	}
+	if l.BytesPerSec > 0 {
+		rl.bytes = newTokenBucket(l.BytesPerSec, l.BytesBurst)
+	}
+	return rl
+}
+
+// wait returns how long to wait before a message of the given size is
+// within the limit.
+func (rl *rateLimiter) wait(now time.Time, size int) time.Duration {
+	var d time.Duration
+	if rl.msgs != nil {
+		d = rl.msgs.wait(now, 1)
+	}
+	if rl.bytes != nil {
+		if bd := rl.bytes.wait(now, float64(size)); bd > d {
+			d = bd
+		}
+	}
+	return d
+}
+
+// take takes the tokens for a message of the given size.
+func (rl *rateLimiter) take(size int) {
+	if rl.msgs != nil {
+		rl.msgs.tokens--
+	}
+	if rl.bytes != nil {
+		rl.bytes.tokens -= float64(size)
+	}
+}
+
+// publishLimiter enforces the publish rate limits of a connection.
+type publishLimiter struct {
+	mu         sync.Mutex
+	global     *rateLimiter // nil if not set
+	perSubject RateLimit
+	subjects   map[string]*rateLimiter // nil if no limit per subject
+}
+
+// newPublishLimiter returns the limiter for the given options, or nil if no
+// publish rate limit is set.
+func newPublishLimiter(o *Options) *publishLimiter {
+	if !o.PublishRateLimit.isSet() && !o.PublishRateLimitPerSubject.isSet() {
+		return nil
+	}
+	pl := &publishLimiter{}
+	if o.PublishRateLimit.isSet() {
+		pl.global = newRateLimiter(o.PublishRateLimit)
+	}
+	if o.PublishRateLimitPerSubject.isSet() {
+		pl.perSubject = o.PublishRateLimitPerSubject
+		pl.subjects = make(map[string]*rateLimiter)
+	}
+	return pl
+}
+
+// acquire waits, if block is true, until a message of the given size can
+// be published to the subject. If block is false, ErrRateLimited is returned
+// instead of waiting. Returns ErrConnectionClosed if closeCh is closed while
+// waiting.
+func (pl *publishLimiter) acquire(subject string, size int, block bool, closeCh chan struct{}) error {
+	pl.mu.Lock()
+	now := time.Now()
+	var d time.Duration
+	if pl.global != nil {
+		d = pl.global.wait(now, size)
+	}
+	var sl *rateLimiter
+	if pl.subjects != nil {
+		if sl = pl.subjects[subject]; sl == nil {
+			sl = newRateLimiter(pl.perSubject)
+			pl.subjects[subject] = sl
+		}
+		if sd := sl.wait(now, size); sd > d {
+			d = sd
+		}
+	}
+	if d > 0 && !block {
+		pl.mu.Unlock()
+		return ErrRateLimited
+	}
+	// Reserve the tokens now so that concurrent publishers wait in turn.
+	if pl.global != nil {
+		pl.global.take(size)
+	}
+	if sl != nil {
+		sl.take(size)
+	}
+	pl.mu.Unlock()
+
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-closeCh:
+		return ErrConnectionClosed
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2026 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stan
+
+import (
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// PublishWithReply will publish to the cluster a message with the given
+// reply subject and wait for an ACK.
+func (sc *conn) PublishWithReply(subject, reply string, data []byte) error {
+	// See Publish for why the channel is buffered.
+	ch := make(chan error, 1)
+	_, err := sc.publishAsync(subject, reply, nil, data, nil, ch)
+	if err == nil {
+		err = <-ch
+	}
+	return err
+}
+
+// Request will publish to the cluster a message with a unique reply subject
+// and wait for a response up to the given timeout, which also covers waiting
+// for the ACK of the publish.
+func (sc *conn) Request(subject string, data []byte, timeout time.Duration) (*nats.Msg, error) {
+	deadline := time.Now().Add(timeout)
+	// sc.nc is immutable and never nil once connection is created.
+	inbox := nats.NewInbox()
+	rsub, err := sc.nc.SubscribeSync(inbox)
+	if err != nil {
+		return nil, err
+	}
+	defer rsub.Unsubscribe()
+	rsub.AutoUnsubscribe(1)
+
+	// The publish may block on the rate limit or MaxPubAcksInflight, so it
+	// is done in a go routine to stop waiting once the timeout expires.
+	// See Publish for why the channel is buffered.
+	ch := make(chan error, 1)
+	go func() {
+		if _, err := sc.publishAsync(subject, inbox, nil, data, nil, ch); err != nil {
+			ch <- err
+		}
+	}()
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	select {
+	case err := <-ch:
+		if err != nil {
+			return nil, err
+		}
+	case <-t.C:
+		return nil, ErrRequestTimeout
+	}
+	wait := time.Until(deadline)
+	if wait <= 0 {
+		return nil, ErrRequestTimeout
+	}
+	resp, err := rsub.NextMsg(wait)
+	if err == nats.ErrTimeout {
+		return nil, ErrRequestTimeout
+	}
+	return resp, err
+}
+
+// Respond sends a response to the message's reply subject, as set with
+// PublishWithReply or Conn.Request. The response is sent with core NATS and
+// is not persisted. Returns ErrNoReply if the message has no reply subject.
+func (msg *Msg) Respond(data []byte) error {
+	if msg == nil {
+		return ErrNilMsg
+	}
+	if msg.Reply == "" {
+		return ErrNoReply
+	}
+	// Look up subscription (cannot be nil)
+	sc := msg.Sub.(*subscription).sc
+	// sc.nc is immutable and never nil once connection is created.
+	err := sc.nc.Publish(msg.Reply, data)
+	if err == nats.ErrConnectionClosed {
+		return ErrBadConnection
+	}
+	return err
+}
@@ -0,0 +1,281 @@
+// Copyright 2026 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stan
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sync"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/stan.go/pb"
+)
+
+// SchemaIDHeader is the message header carrying the ID of the schema the
+// payload was validated against when published.
+const SchemaIDHeader = "Stan-Schema-Id"
+
+// Errors related to schemas.
+var (
+	ErrUnknownSchema      = errors.New("stan: unknown schema")
+	ErrIncompatibleSchema = errors.New("stan: incompatible schema")
+	ErrInvalidPayload     = errors.New("stan: payload does not match schema")
+)
+
+// SchemaRegistry maps channels to the ID of the schema of their messages,
+// and validates payloads against schemas.
+type SchemaRegistry interface {
+	// SchemaID returns the ID of the schema of the channel, or an empty
+	// string if the channel has no schema.
+	SchemaID(channel string) (string, error)
+	// Validate returns an error wrapping ErrInvalidPayload if the data does
+	// not match the schema, or ErrUnknownSchema if the schema is unknown.
+	Validate(schemaID string, data []byte) error
+	// Compatible returns whether messages with the writer schema can be
+	// consumed by consumers of the reader schema. Returns an error wrapping
+	// ErrUnknownSchema if either schema is unknown.
+	Compatible(writer, reader string) (bool, error)
+}
+
+// SchemaPolicy specifies what happens to a delivered message that is
+// rejected because of its schema.
+type SchemaPolicy int
+
+const (
+	// SchemaReject acknowledges the message without passing it to the
+	// subscription's handler. This is the default.
+	SchemaReject SchemaPolicy = iota
+	// SchemaDeadLetter publishes the message's data and header to the
+	// subject set with the DeadLetterSubject option and acknowledges the
+	// message if the publish succeeds. If it fails, the message is not
+	// acknowledged.
+	SchemaDeadLetter
+)
+
+// SchemaErrorHandler is used to be notified of delivered messages that are
+// rejected because of their schema.
+type SchemaErrorHandler func(msg *Msg, err error)
+
+// UseSchemaRegistry is an Option to use a schema registry for the channels
+// the connection publishes to and subscribes on.
+//
+// Messages published to a channel that has a schema are validated and tagged
+// with the schema ID in the SchemaIDHeader header. A publish call fails if
+// the payload is invalid.
+//
+// Messages delivered on a channel that has a schema are checked before being
+// passed to the subscription's handler. The message is rejected if its schema
+// is unknown, not compatible with the channel's schema, or if the payload does
+// not match it. Messages without schema ID, for instance published before the
+// channel had a schema, are validated against the channel's schema. Rejected
+// messages are handled according to the policy and, if not nil, the handler
+// is invoked. The SchemaDeadLetter policy requires the DeadLetterSubject option.
+func UseSchemaRegistry(registry SchemaRegistry, policy SchemaPolicy, handler SchemaErrorHandler) Option {
+	return func(o *Options) error {
+		o.SchemaRegistry = registry
+		o.SchemaPolicy = policy
+		o.SchemaErrorCB = handler
+		return nil
+	}
+}
+
+// tagSchema validates the data against the schema of the subject, if any,
+// and returns the header with the schema ID set. The given header is not
+// modified.
+func tagSchema(reg SchemaRegistry, subject string, header nats.Header, data []byte) (nats.Header, error) {
+	id, err := reg.SchemaID(subject)
+	if err != nil || id == "" {
+		return header, err
+	}
+	if err := reg.Validate(id, data); err != nil {
+		return nil, err
+	}
+	tagged := make(nats.Header, len(header)+1)
+	for k, v := range header {
+		tagged[k] = v
+	}
+	tagged.Set(SchemaIDHeader, id)
+	return tagged, nil
+}
+
+// checkSchema returns an error if the message does not conform to the
+// schema of its channel.
+func checkSchema(reg SchemaRegistry, msg *Msg) error {
+	want, err := reg.SchemaID(msg.Subject)
+	if err != nil || want == "" {
+		return err
+	}
+	got := msg.header.Get(SchemaIDHeader)
+	if got == "" {
+		got = want
+	}
+	if got != want {
+		ok, err := reg.Compatible(got, want)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("%w: %q cannot be read as %q", ErrIncompatibleSchema, got, want)
+		}
+	}
+	return reg.Validate(got, msg.Data)
+}
+
+// rejectMsg notifies the SchemaErrorCB handler and applies the SchemaPolicy
+// to a message that failed the schema check.
+func (sc *conn) rejectMsg(sub *subscription, msg *Msg, err error) {
+	// Options are immutable.
+	if cb := sc.opts.SchemaErrorCB; cb != nil {
+		cb(msg, err)
+	}
+	if sc.opts.SchemaPolicy == SchemaDeadLetter {
+		if sc.deadLetter(msg) != nil {
+			return
+		}
+	}
+	sub.RLock()
+	ackSubject := sub.ackInbox
+	closed := sub.closed
+	sub.RUnlock()
+	if closed {
+		return
+	}
+	ack := &pb.Ack{Subject: msg.Subject, Sequence: msg.Sequence}
+	b, _ := ack.Marshal()
+	// sc.nc is immutable and never nil once connection is created.
+	if sc.nc.Publish(ackSubject, b) == nil {
+		sub.recordAck(msg.Sequence)
+	}
+}
+
+// FileSchemaRegistry is a SchemaRegistry backed by a JSON file of the form:
+//
+//	{
+//	  "channels": {"orders": "order-v2"},
+//	  "schemas": {
+//	    "order-v1": {"schema": {"type": "object", "required": ["id"]}},
+//	    "order-v2": {
+//	      "schema": {"type": "object", "required": ["id", "total"]},
+//	      "compatible": ["order-v1"]
+//	    }
+//	  }
+//	}
+//
+// where "compatible" lists the schemas whose messages can be consumed by
+// consumers of the schema. Schemas are written in a subset of JSON Schema:
+// the type, enum, const, properties, required, additionalProperties, items,
+// minItems, maxItems, minLength, maxLength, pattern, minimum, maximum,
+// exclusiveMinimum and exclusiveMaximum keywords are supported, along with
+// annotations such as title and description. Other keywords are rejected.
+type FileSchemaRegistry struct {
+	path     string
+	mu       sync.RWMutex
+	channels map[string]string
+	schemas  map[string]*registeredSchema
+}
+
+type registeredSchema struct {
+	schema     *jsonSchema
+	compatible map[string]bool
+}
+
+type schemaFile struct {
+	Channels map[string]string `json:"channels"`
+	Schemas  map[string]struct {
+		Schema     json.RawMessage `json:"schema"`
+		Compatible []string        `json:"compatible"`
+	} `json:"schemas"`
+}
+
+// NewFileSchemaRegistry returns a SchemaRegistry loaded from the given file.
+func NewFileSchemaRegistry(path string) (*FileSchemaRegistry, error) {
+	r := &FileSchemaRegistry{path: path}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload loads the file again. On error, the registry is left unchanged.
+func (r *FileSchemaRegistry) Reload() error {
+	b, err := ioutil.ReadFile(r.path)
+	if err != nil {
+		return err
+	}
+	var f schemaFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return fmt.Errorf("stan: invalid schema registry %q: %v", r.path, err)
+	}
+	schemas := make(map[string]*registeredSchema, len(f.Schemas))
+	for id, s := range f.Schemas {
+		js, err := compileJSONSchema(s.Schema)
+		if err != nil {
+			return fmt.Errorf("stan: invalid schema %q in %q: %v", id, r.path, err)
+		}
+		rs := &registeredSchema{schema: js, compatible: make(map[string]bool, len(s.Compatible))}
+		for _, c := range s.Compatible {
+			rs.compatible[c] = true
+		}
+		schemas[id] = rs
+	}
+	for id, s := range schemas {
+		for c := range s.compatible {
+			if schemas[c] == nil {
+				return fmt.Errorf("stan: schema %q in %q is compatible with unknown schema %q", id, r.path, c)
+			}
+		}
+	}
+	for channel, id := range f.Channels {
+		if schemas[id] == nil {
+			return fmt.Errorf("stan: channel %q in %q has unknown schema %q", channel, r.path, id)
+		}
+	}
+	r.mu.Lock()
+	r.channels = f.Channels
+	r.schemas = schemas
+	r.mu.Unlock()
+	return nil
+}
+
+// SchemaID implements the SchemaRegistry interface.
+func (r *FileSchemaRegistry) SchemaID(channel string) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.channels[channel], nil
+}
+
+// Validate implements the SchemaRegistry interface.
+func (r *FileSchemaRegistry) Validate(schemaID string, data []byte) error {
+	r.mu.RLock()
+	s := r.schemas[schemaID]
+	r.mu.RUnlock()
+	if s == nil {
+		return fmt.Errorf("%w: %q", ErrUnknownSchema, schemaID)
+	}
+	return s.schema.validateJSON(data)
+}
+
+// Compatible implements the SchemaRegistry interface.
+func (r *FileSchemaRegistry) Compatible(writer, reader string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, id := range []string{writer, reader} {
+		if r.schemas[id] == nil {
+			return false, fmt.Errorf("%w: %q", ErrUnknownSchema, id)
+		}
+	}
+	return writer == reader || r.schemas[reader].compatible[writer], nil
+}
@@ -0,0 +1,119 @@
+// Copyright 2026 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stan
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+)
+
+// SignatureHeader is the NATS header carrying the base64 encoded ed25519
+// signature of the payload of a publish ACK or a delivered message.
+const SignatureHeader = "Stan-Signature"
+
+// Errors related to signature verification.
+var (
+	ErrBadAckSignature = errors.New("stan: invalid publish ack signature")
+	ErrBadMsgSignature = errors.New("stan: invalid message signature")
+	ErrNoPublicKey     = errors.New("stan: no public key to verify signatures")
+)
+
+// BadSignatureHandler is used to be notified of delivered messages whose
+// signature is missing or invalid. Such messages are not passed to the
+// subscription's handler and are not acknowledged.
+type BadSignatureHandler func(msg *Msg, err error)
+
+// VerifyAckSignatures is an Option to verify the signature of publish ACKs
+// with the public key pinned with PinPublicKey or, if not pinned, the one
+// sent by the server when the connection is created. Connect returns
+// ErrNoPublicKey if there is no key.
+//
+// A publish call whose ACK has a missing or invalid signature fails with
+// ErrBadAckSignature. As with a timeout, the message may or may not have
+// been persisted by the server.
+func VerifyAckSignatures() Option {
+	return func(o *Options) error {
+		o.VerifyAckSignatures = true
+		return nil
+	}
+}
+
+// VerifyMsgSignatures is an Option to verify the signature of the messages
+// delivered to the subscriptions of this connection, with the same key as
+// VerifyAckSignatures. Messages with a missing or invalid signature are
+// dropped and, if not nil, the handler is invoked with ErrBadMsgSignature.
+func VerifyMsgSignatures(handler BadSignatureHandler) Option {
+	return func(o *Options) error {
+		o.VerifyMsgSignatures = true
+		o.BadSignatureCB = handler
+		return nil
+	}
+}
+
+// PinPublicKey is an Option to set the public key used to verify signatures
+// instead of the one sent by the server.
+func PinPublicKey(key ed25519.PublicKey) Option {
+	return func(o *Options) error {
+		if len(key) != ed25519.PublicKeySize {
+			return fmt.Errorf("stan: invalid public key size %v", len(key))
+		}
+		o.PublicKey = key
+		return nil
+	}
+}
+
+// EncodePublicKey returns the representation of the public key expected in
+// the publicKey field of the server's connect response.
+func EncodePublicKey(key ed25519.PublicKey) string {
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+// Sign adds to the message the signature of its payload with the given key.
+// This is meant for servers (or stand-ins used in tests) signing publish
+// ACKs and delivered messages.
+func Sign(m *nats.Msg, key ed25519.PrivateKey) {
+	if m.Header == nil {
+		m.Header = nats.Header{}
+	}
+	m.Header.Set(SignatureHeader, base64.StdEncoding.EncodeToString(ed25519.Sign(key, m.Data)))
+}
+
+// Returns the key used to verify signatures: the pinned one if any,
+// otherwise the one sent by the server.
+func (o *Options) verificationKey(serverKey string) (ed25519.PublicKey, error) {
+	if o.PublicKey != nil {
+		return o.PublicKey, nil
+	}
+	if serverKey == "" {
+		return nil, ErrNoPublicKey
+	}
+	key, err := base64.StdEncoding.DecodeString(serverKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("stan: invalid server public key %q", serverKey)
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// Returns true if the message carries a valid signature of its payload.
+func verifySignature(key ed25519.PublicKey, m *nats.Msg) bool {
+	sig, err := base64.StdEncoding.DecodeString(m.Header.Get(SignatureHeader))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return false
+	}
+	return ed25519.Verify(key, m.Data, sig)
+}
@@ -15,8 +15,11 @@
 package stan
 
 import (
+	"context"
+	"crypto/ed25519"
 	"errors"
 	"fmt"
+	"runtime/debug"
 	"sync"
 	"time"
 
@@ -71,6 +74,51 @@ type Conn interface {
 	// registered in the cluster).
 	QueueSubscribe(subject, qgroup string, cb MsgHandler, opts ...SubscriptionOption) (Subscription, error)
 
+	// PublishWithReply will publish to the cluster a message with the given
+	// reply subject, which is stored with the message and set in Msg.Reply
+	// when delivered, and wait for an ACK.
+	PublishWithReply(subject, reply string, data []byte) error
+
+	// Request will publish to the cluster a message with a unique reply
+	// subject and wait, up to the given timeout, for a response sent with
+	// Msg.Respond() to this subject. The timeout also covers waiting for
+	// the ACK of the publish. Returns ErrRequestTimeout if no response was
+	// received in time. Note that the request may still be persisted and
+	// processed after the timeout.
+	Request(subject string, data []byte, timeout time.Duration) (*nats.Msg, error)
+
+	// PublishMsg will publish the message to the cluster and wait for an ACK.
+	// The message header is stored with the data and available with
+	// Msg.Header() when delivered.
+	PublishMsg(msg *OutMsg) error
+
+	// SubscribeE will perform a subscription with the given options to the cluster,
+	// invoking a handler that returns an error. The subscription is in manual-ack
+	// mode, and the library acknowledges a message when the handler returns nil.
+	// When the handler returns an error, the message is not acknowledged and is
+	// redelivered by the cluster after AckWait, unless the error was wrapped with
+	// DeadLetter or Redeliver.
+	SubscribeE(subject string, cb MsgHandlerE, opts ...SubscriptionOption) (Subscription, error)
+
+	// QueueSubscribeE will perform a queue subscription with the given options to the
+	// cluster, invoking a handler that returns an error. See SubscribeE for details.
+	QueueSubscribeE(subject, qgroup string, cb MsgHandlerE, opts ...SubscriptionOption) (Subscription, error)
+
+	// ChanSubscribe will perform a subscription with the given options to the cluster
+	// and deliver messages to the given Go channel.
+	//
+	// Delivery blocks while the channel is full, so a slow consumer causes the cluster
+	// to stop sending messages once MaxInflight messages are outstanding. In auto-ack
+	// mode, a message is acknowledged once it has been placed on the channel. Use
+	// SetManualAckMode() to acknowledge messages after they have been processed.
+	// Messages not yet placed on the channel when the subscription is closed are
+	// not acknowledged. The channel is not closed by the library.
+	ChanSubscribe(subject string, ch chan *Msg, opts ...SubscriptionOption) (Subscription, error)
+
+	// ChanQueueSubscribe will perform a queue subscription with the given options to the
+	// cluster and deliver messages to the given Go channel. See ChanSubscribe for details.
+	ChanQueueSubscribe(subject, qgroup string, ch chan *Msg, opts ...SubscriptionOption) (Subscription, error)
+
 	// Close a connection to the cluster.
 	//
 	// If there are active subscriptions at the time of the close, they are implicitly closed
@@ -89,6 +137,34 @@ type Conn interface {
 	// example, closing the wrapped NATS conn will put the NATS Streaming Conn
 	// in an invalid state.
 	NatsConn() *nats.Conn
+
+	// Status returns the current status of the connection, which takes into
+	// account the state of the underlying NATS connection and the PINGs sent
+	// to the streaming server.
+	Status() Status
+
+	// PingStats returns the round-trip time statistics of the PINGs sent
+	// to the streaming server and the number of consecutive missed PINGs.
+	PingStats() PingStats
+
+	// Ping sends a PING to the streaming server and waits for the response,
+	// returning the round-trip time. Unlike a NATS level flush, this checks
+	// that the streaming server is reachable and that it still considers
+	// this connection valid. Returns ErrNoServerSupport if the server does
+	// not support client PINGs.
+	Ping(ctx context.Context) (time.Duration, error)
+
+	// PendingPubAcks returns the number of published messages for which
+	// the ACK from the server has not yet been received.
+	PendingPubAcks() int
+
+	// ClientID returns the client ID used by this connection, which may
+	// differ from the one given to Connect depending on the ClientIDStrategy.
+	ClientID() string
+
+	// ServerInfo returns the protocol details negotiated with the streaming
+	// server, which allows applications to check which features are supported.
+	ServerInfo() ServerInfo
 }
 
 const (
@@ -113,6 +189,12 @@ var (
 	ErrNilMsg            = errors.New("stan: nil message")
 	ErrNoServerSupport   = errors.New("stan: not supported by server")
 	ErrMaxPings          = errors.New("stan: connection lost due to PING failure")
+	ErrNilChan           = errors.New("stan: nil channel")
+	ErrNoDeadLetter      = errors.New("stan: dead-letter subject not set")
+	ErrNilHandler        = errors.New("stan: nil message handler")
+	ErrRateLimited       = errors.New("stan: publish rate limit exceeded")
+	ErrRequestTimeout    = errors.New("stan: request timeout")
+	ErrNoReply           = errors.New("stan: message has no reply subject")
 )
 
 var testAllowMillisecInPings = false
@@ -126,6 +208,27 @@ type AckHandler func(string, error)
 // is closed due to unexpected errors.
 type ConnectionLostHandler func(Conn, error)
 
+// PanicHandler is used to be notified when a MsgHandler panics while the
+// RecoverPanics option is set. The recovered value and the stack trace of
+// the go routine that panicked are passed along with the message.
+type PanicHandler func(msg *Msg, r interface{}, stack []byte)
+
+// PanicPolicy specifies what happens to a message whose handler panicked
+// when the RecoverPanics option is set.
+type PanicPolicy int
+
+const (
+	// PanicNoAck leaves the message unacknowledged, so that it is
+	// redelivered by the cluster after the subscription's AckWait.
+	PanicNoAck PanicPolicy = iota
+	// PanicAck acknowledges the message, even in manual-ack mode.
+	PanicAck
+	// PanicDeadLetter publishes the message's data and header to the
+	// subject set with the DeadLetterSubject option and acknowledges the
+	// message if the publish succeeds. If it fails, the message is not acknowledged.
+	PanicDeadLetter
+)
+
 // Options can be used to a create a customized connection.
 type Options struct {
 	// NatsURL is an URL (or comma separated list of URLs) to a node or nodes
@@ -180,6 +283,91 @@ type Options struct {
 	// is permanently lost.
 	ConnectionLostCB ConnectionLostHandler
 
+	// Middlewares are applied to the handlers of all subscriptions created
+	// with this connection.
+	Middlewares []Middleware
+
+	// RecoverPanics specifies that a panic in a MsgHandler is recovered
+	// instead of crashing the process. PanicPolicy then determines what
+	// happens to the message, and PanicCB, if set, is notified.
+	RecoverPanics bool
+
+	// PanicPolicy specifies what happens to a message whose handler
+	// panicked when RecoverPanics is set.
+	PanicPolicy PanicPolicy
+
+	// PanicCB specifies the handler to be invoked when a MsgHandler
+	// panicked and RecoverPanics is set.
+	PanicCB PanicHandler
+
+	// DeadLetterSubject is the subject messages are published to when they
+	// are dead-lettered, for instance with the PanicDeadLetter policy.
+	DeadLetterSubject string
+
+	// StatusChangedCB specifies the handler to be invoked when the status
+	// of the connection changes.
+	StatusChangedCB StatusChangedHandler
+
+	// ClientIDStrategy specifies how the client ID is generated from the
+	// one given to Connect.
+	ClientIDStrategy ClientIDStrategy
+
+	// DuplicateClientIDRetries is the number of times Connect is retried
+	// when the server reports that the client ID is already registered.
+	DuplicateClientIDRetries int
+
+	// DuplicateClientIDBackoff is the time to wait before the first retry
+	// when the client ID is already registered. It doubles after each attempt.
+	DuplicateClientIDBackoff time.Duration
+
+	// VerifyAckSignatures specifies that the signature of publish ACKs
+	// is verified.
+	VerifyAckSignatures bool
+
+	// VerifyMsgSignatures specifies that the signature of delivered
+	// messages is verified.
+	VerifyMsgSignatures bool
+
+	// BadSignatureCB specifies the handler to be invoked when a delivered
+	// message has a missing or invalid signature.
+	BadSignatureCB BadSignatureHandler
+
+	// PublicKey is the pinned key used to verify signatures. If not set,
+	// the key sent by the server is used.
+	PublicKey ed25519.PublicKey
+
+	// PublishRateLimit is the rate limit of messages published by the
+	// connection, across all subjects.
+	PublishRateLimit RateLimit
+
+	// PublishRateLimitPerSubject is the rate limit of messages published
+	// by the connection to each subject.
+	PublishRateLimitPerSubject RateLimit
+
+	// PublishRateLimitMode specifies whether PublishAsync blocks or fails
+	// with ErrRateLimited when the publish rate limit is exceeded.
+	PublishRateLimitMode RateLimitMode
+
+	// SchemaRegistry, if set, is used to tag and validate published
+	// messages, and to check the schema of delivered messages.
+	SchemaRegistry SchemaRegistry
+
+	// SchemaPolicy specifies what happens to a delivered message whose
+	// schema is unknown or incompatible, or whose payload is invalid.
+	SchemaPolicy SchemaPolicy
+
+	// SchemaErrorCB specifies the handler to be invoked when a delivered
+	// message is rejected because of its schema.
+	SchemaErrorCB SchemaErrorHandler
+
+	// FailoverCB specifies the handler to be invoked when a connection
+	// created with ConnectWithFailover switches to another cluster.
+	FailoverCB FailoverHandler
+
+	// ResubscribeErrorCB specifies the handler to be invoked when a
+	// subscription could not be re-established after a failover.
+	ResubscribeErrorCB ResubscribeErrorHandler
+
 	// AllowCloseRetry specifies that a failed connection Close() can be retried.
 	//
 	// By default, after the first call to Close(), the underlying NATS connection
@@ -332,6 +520,29 @@ func AllowCloseRetry(allow bool) Option {
 	}
 }
 
+// RecoverPanics is an Option to recover from panics in MsgHandlers instead
+// of crashing the process. The given policy determines whether the message
+// is acknowledged, left unacknowledged or dead-lettered, and the handler,
+// if not nil, is notified with the message, recovered value and stack trace.
+// The PanicDeadLetter policy requires the DeadLetterSubject option.
+func RecoverPanics(policy PanicPolicy, handler PanicHandler) Option {
+	return func(o *Options) error {
+		o.RecoverPanics = true
+		o.PanicPolicy = policy
+		o.PanicCB = handler
+		return nil
+	}
+}
+
+// DeadLetterSubject is an Option to set the subject messages are published
+// to when they are dead-lettered.
+func DeadLetterSubject(subject string) Option {
+	return func(o *Options) error {
+		o.DeadLetterSubject = subject
+		return nil
+	}
+}
+
 // A conn represents a bare connection to a stan cluster.
 type conn struct {
 	sync.RWMutex
@@ -357,6 +568,10 @@ type conn struct {
 	closed           bool
 	fullyClosed      bool
 	ping             pingInfo
+	status           statusInfo
+	info             ServerInfo        // Immutable once the connection is created.
+	verifyKey        ed25519.PublicKey // Immutable, set if signatures are verified.
+	limiter          *publishLimiter   // Immutable, set if publish rate is limited.
 }
 
 // Holds all field related to the client-to-server pings
@@ -370,6 +585,66 @@ type pingInfo struct {
 	interval time.Duration
 	maxOut   int
 	out      int
+	sent     time.Time // Time the last PING was sent, zero once measured.
+	lastRTT  time.Duration
+	rttSum   time.Duration
+	rttCount int64
+}
+
+// PingStats holds the statistics of the PINGs sent by a connection to the
+// streaming server.
+type PingStats struct {
+	// LastRTT is the round-trip time of the last PING that got a response.
+	LastRTT time.Duration
+	// AvgRTT is the average round-trip time of all PINGs that got a response.
+	AvgRTT time.Duration
+	// Count is the number of PINGs whose round-trip time was measured.
+	Count int64
+	// Missed is the number of consecutive PINGs that did not get a response.
+	Missed int
+}
+
+// ServerInfo holds the protocol details negotiated with the streaming
+// server when the connection was created.
+type ServerInfo struct {
+	// Protocol is the protocol version of the server.
+	Protocol int
+	// PingInterval is the interval at which the connection sends PINGs to
+	// the server, as chosen by the server. Zero if the server does not
+	// support client PINGs.
+	PingInterval time.Duration
+	// PingMaxOut is the number of PINGs without a response after which
+	// the connection is considered lost, as chosen by the server.
+	PingMaxOut int
+	// PingSupported is true if the server supports client PINGs, and
+	// therefore Conn.Ping().
+	PingSupported bool
+	// SubCloseSupported is true if the server supports closing durable
+	// subscriptions, that is, Subscription.Close().
+	SubCloseSupported bool
+	// PublicKey is the public key of the server, if any.
+	PublicKey string
+	// PubPrefix is the prefix of the subjects messages are published to.
+	PubPrefix string
+	// SubRequests is the subject subscription requests are sent to.
+	SubRequests string
+	// UnsubRequests is the subject unsubscribe requests are sent to.
+	UnsubRequests string
+	// SubCloseRequests is the subject subscription close requests are sent
+	// to, empty if not supported.
+	SubCloseRequests string
+	// CloseRequests is the subject connection close requests are sent to.
+	CloseRequests string
+	// PingRequests is the subject PINGs are sent to, empty if not supported.
+	PingRequests string
+}
+
+// recordRTT updates the round-trip time statistics.
+// Ping lock is held on entry.
+func (p *pingInfo) recordRTT(rtt time.Duration) {
+	p.lastRTT = rtt
+	p.rttSum += rtt
+	p.rttCount++
 }
 
 // Closure for ack contexts.
@@ -385,19 +660,47 @@ type ack struct {
 // specified in a parameter here overrides those defaults.
 func Connect(stanClusterID, clientID string, options ...Option) (Conn, error) {
 	// Process Options
+	opts := getDefaultOptions()
+	for _, opt := range options {
+		if err := opt(&opts); err != nil {
+			return nil, err
+		}
+	}
+	if opts.RecoverPanics && opts.PanicPolicy == PanicDeadLetter && opts.DeadLetterSubject == "" {
+		return nil, ErrNoDeadLetter
+	}
+	if opts.SchemaRegistry != nil && opts.SchemaPolicy == SchemaDeadLetter && opts.DeadLetterSubject == "" {
+		return nil, ErrNoDeadLetter
+	}
+	backoff := opts.DuplicateClientIDBackoff
+	for attempt := 0; ; attempt++ {
+		id, err := opts.generateClientID(clientID)
+		if err != nil {
+			return nil, err
+		}
+		sc, err := connect(stanClusterID, id, opts)
+		if err == nil {
+			return sc, nil
+		}
+		if attempt >= opts.DuplicateClientIDRetries || !isDuplicateClientID(err) {
+			return nil, err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// connect makes a single connection attempt with the given client ID.
+func connect(stanClusterID, clientID string, opts Options) (*conn, error) {
 	c := conn{
 		clientID:        clientID,
-		opts:            getDefaultOptions(),
+		opts:            opts,
 		connID:          []byte(nuid.Next()),
 		pubNUID:         nuid.New(),
 		pubAckMap:       make(map[string]*ack),
 		pubAckCloseChan: make(chan struct{}),
 		subMap:          make(map[string]*subscription),
-	}
-	for _, opt := range options {
-		if err := opt(&c.opts); err != nil {
-			return nil, err
-		}
+		limiter:         newPublishLimiter(&opts),
 	}
 	// Check if the user has provided a connection as an option
 	c.nc = c.opts.NatsConn
@@ -413,6 +716,9 @@ func Connect(stanClusterID, clientID string, options ...Option) (Conn, error) {
 		if do.Name == "" {
 			nopts = append(nopts, nats.Name(clientID))
 		}
+		if c.opts.StatusChangedCB != nil {
+			nopts = append(nopts, c.statusNatsOptions(&do)...)
+		}
 		// We will set the max reconnect attempts to -1 (infinite)
 		// and the reconnect buffer to -1 to prevent any buffering
 		// (which may cause a published message to be flushed on
@@ -495,6 +801,13 @@ func Connect(stanClusterID, clientID string, options ...Option) (Conn, error) {
 	}
 	c.ackSubscription.SetPendingLimits(-1, -1)
 
+	if c.opts.VerifyAckSignatures || c.opts.VerifyMsgSignatures {
+		if c.verifyKey, err = c.opts.verificationKey(cr.PublicKey); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+
 	c.pubAckChan = make(chan struct{}, c.opts.MaxPubAcksInflight)
 
 	// Capture the connection error cb
@@ -537,6 +850,21 @@ func Connect(stanClusterID, clientID string, options ...Option) (Conn, error) {
 		p.sub = nil
 	}
 
+	c.info = ServerInfo{
+		Protocol:          int(cr.Protocol),
+		PingInterval:      p.interval,
+		PingMaxOut:        p.maxOut,
+		PingSupported:     p.requests != "",
+		SubCloseSupported: cr.SubCloseRequests != "",
+		PublicKey:         cr.PublicKey,
+		PubPrefix:         cr.PubPrefix,
+		SubRequests:       cr.SubRequests,
+		UnsubRequests:     cr.UnsubRequests,
+		SubCloseRequests:  cr.SubCloseRequests,
+		CloseRequests:     cr.CloseRequests,
+		PingRequests:      p.requests,
+	}
+
 	return &c, nil
 }
 
@@ -570,7 +898,9 @@ func (sc *conn) pingServer() {
 		return
 	}
 	p.timer.Reset(p.interval)
+	p.sent = time.Now()
 	p.mu.Unlock()
+	sc.checkStatus()
 	// Send the PING now. If the NATS connection is reported closed, we are done.
 	// sc.nc is immutable and never nil, even if connection is closed.
 	if err := sc.nc.PublishRequest(p.requests, p.inbox, p.proto); err == nats.ErrConnectionClosed {
@@ -606,7 +936,73 @@ func (sc *conn) processPingResponse(m *nats.Msg) {
 	p := &sc.ping
 	p.mu.Lock()
 	p.out = 0
+	// Measure only the first response for a given PING.
+	if !p.sent.IsZero() {
+		p.recordRTT(time.Since(p.sent))
+		p.sent = time.Time{}
+	}
 	p.mu.Unlock()
+	sc.checkStatus()
+}
+
+// ServerInfo returns the protocol details negotiated with the streaming server.
+func (sc *conn) ServerInfo() ServerInfo {
+	return sc.info
+}
+
+// PingStats returns the round-trip time statistics of the PINGs sent to the
+// streaming server and the number of consecutive missed PINGs.
+func (sc *conn) PingStats() PingStats {
+	p := &sc.ping
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ps := PingStats{LastRTT: p.lastRTT, Count: p.rttCount}
+	if p.rttCount > 0 {
+		ps.AvgRTT = p.rttSum / time.Duration(p.rttCount)
+	}
+	// One PING is outstanding between the time it is sent and the
+	// time the response is received.
+	if p.out > 1 {
+		ps.Missed = p.out - 1
+	}
+	return ps
+}
+
+// Ping sends a PING to the streaming server and waits for the response.
+func (sc *conn) Ping(ctx context.Context) (time.Duration, error) {
+	sc.RLock()
+	closed := sc.closed
+	sc.RUnlock()
+	if closed {
+		return 0, ErrConnectionClosed
+	}
+	// These are immutable and set only if the server supports PINGs.
+	p := &sc.ping
+	if p.requests == "" {
+		return 0, ErrNoServerSupport
+	}
+	start := time.Now()
+	// sc.nc is immutable and never nil once connection is created.
+	resp, err := sc.nc.RequestWithContext(ctx, p.requests, p.proto)
+	if err != nil {
+		return 0, err
+	}
+	rtt := time.Since(start)
+	if len(resp.Data) > 0 {
+		pingResp := &pb.PingResponse{}
+		if err := pingResp.Unmarshal(resp.Data); err != nil {
+			return 0, err
+		}
+		if pingResp.Error != "" {
+			return 0, errors.New(pingResp.Error)
+		}
+	}
+	p.mu.Lock()
+	p.out = 0
+	p.recordRTT(rtt)
+	p.mu.Unlock()
+	sc.checkStatus()
+	return rtt, nil
 }
 
 // Closes a connection and invoke the connection error callback if one
@@ -629,6 +1025,7 @@ func (sc *conn) closeDueToPing(err error) {
 	// Capture callback (even though this is immutable).
 	cb := sc.connLostCB
 	sc.Unlock()
+	sc.checkStatus()
 	if cb != nil {
 		// Execute in separate go routine.
 		go cb(sc, err)
@@ -694,6 +1091,8 @@ func (sc *conn) cleanupOnClose(err error) {
 
 // Close a connection to the stan system.
 func (sc *conn) Close() error {
+	// Report the status change after the lock is released.
+	defer sc.checkStatus()
 	sc.Lock()
 	defer sc.Unlock()
 
@@ -752,6 +1151,14 @@ func (sc *conn) NatsConn() *nats.Conn {
 	return nc
 }
 
+// PendingPubAcks returns the number of published messages waiting for an ACK.
+func (sc *conn) PendingPubAcks() int {
+	sc.RLock()
+	n := len(sc.pubAckMap)
+	sc.RUnlock()
+	return n
+}
+
 // Process a heartbeat from the NATS Streaming cluster
 func (sc *conn) processHeartBeat(m *nats.Msg) {
 	// No payload assumed, just reply.
@@ -772,7 +1179,9 @@ func (sc *conn) processAck(m *nats.Msg) {
 	a := sc.removeAck(pa.Guid)
 	if a != nil {
 		// Capture error if it exists.
-		if pa.Error != "" {
+		if sc.opts.VerifyAckSignatures && !verifySignature(sc.verifyKey, m) {
+			err = ErrBadAckSignature
+		} else if pa.Error != "" {
 			err = errors.New(pa.Error)
 		}
 		if a.ah != nil {
@@ -791,7 +1200,7 @@ func (sc *conn) Publish(subject string, data []byte) error {
 	// a publish call is blocked in pubAckChan but cleanupOnClose()
 	// is trying to push the error to this channel.
 	ch := make(chan error, 1)
-	_, err := sc.publishAsync(subject, data, nil, ch)
+	_, err := sc.publishAsync(subject, "", nil, data, nil, ch)
 	if err == nil {
 		err = <-ch
 	}
@@ -801,10 +1210,27 @@ func (sc *conn) Publish(subject string, data []byte) error {
 // PublishAsync will publish to the cluster on pubPrefix+subject and asynchronously
 // process the ACK or error state. It will return the GUID for the message being sent.
 func (sc *conn) PublishAsync(subject string, data []byte, ah AckHandler) (string, error) {
-	return sc.publishAsync(subject, data, ah, nil)
+	return sc.publishAsync(subject, "", nil, data, ah, nil)
 }
 
-func (sc *conn) publishAsync(subject string, data []byte, ah AckHandler, ch chan error) (string, error) {
+func (sc *conn) publishAsync(subject, reply string, header nats.Header, data []byte, ah AckHandler, ch chan error) (string, error) {
+	// Options are immutable.
+	if reg := sc.opts.SchemaRegistry; reg != nil {
+		var err error
+		if header, err = tagSchema(reg, subject, header, data); err != nil {
+			return "", err
+		}
+	}
+	data = EncodeEnvelope(header, data)
+
+	// Publish always blocks on the rate limit.
+	if sc.limiter != nil {
+		block := ch != nil || sc.opts.PublishRateLimitMode == RateLimitBlock
+		if err := sc.limiter.acquire(subject, len(data), block, sc.pubAckCloseChan); err != nil {
+			return "", err
+		}
+	}
+
 	a := &ack{ah: ah, ch: ch}
 	sc.Lock()
 	if sc.closed {
@@ -818,7 +1244,7 @@ func (sc *conn) publishAsync(subject string, data []byte, ah AckHandler, ch chan
 	peGUID := sc.pubNUID.Next()
 	// We send connID regardless of server we connect to. Older server
 	// will simply not decode it.
-	pe := &pb.PubMsg{ClientID: sc.clientID, Guid: peGUID, Subject: subject, Data: data, ConnID: sc.connID}
+	pe := &pb.PubMsg{ClientID: sc.clientID, Guid: peGUID, Subject: subject, Reply: reply, Data: data, ConnID: sc.connID}
 	b, _ := pe.Marshal()
 
 	// Map ack to guid.
@@ -914,6 +1340,7 @@ func (sc *conn) processMsg(raw *nats.Msg) {
 		fmt.Printf("error during message unmarshal: %v\n", err)
 		return
 	}
+	msg.decodeEnvelope()
 	var sub *subscription
 	// Lookup the subscription
 	sc.RLock()
@@ -931,27 +1358,127 @@ func (sc *conn) processMsg(raw *nats.Msg) {
 	// Store in msg for backlink
 	msg.Sub = sub
 
-	sub.RLock()
-	if sub.closed {
-		sub.RUnlock()
+	if sc.opts.VerifyMsgSignatures && !verifySignature(sc.verifyKey, raw) {
+		if sc.opts.BadSignatureCB != nil {
+			sc.opts.BadSignatureCB(msg, ErrBadMsgSignature)
+		}
+		return
+	}
+
+	if sc.opts.SchemaRegistry != nil {
+		if err := checkSchema(sc.opts.SchemaRegistry, msg); err != nil {
+			sc.rejectMsg(sub, msg, err)
+			return
+		}
+	}
+
+	// Wait while the subscription is paused or rate limited.
+	if !sub.waitForDelivery(len(msg.Data)) {
+		return
+	}
+
+	sub.Lock()
+	if sub.closed || sub.stopped {
+		sub.Unlock()
 		return
 	}
-	cb := sub.cb
+	sub.stats.Received++
+	sub.stats.LastReceived = time.Now()
+	sub.stats.LastSequence = msg.Sequence
+	if msg.Redelivered {
+		sub.stats.Redelivered++
+	}
+	handler := sub.handler
 	ackSubject := sub.ackInbox
 	isManualAck := sub.opts.ManualAcks
-	sub.RUnlock()
+	bounded := sub.isBounded()
+	beyondStop := bounded && sub.beyondStop(msg)
+	stopSeq := sub.opts.StopSequence
+	if sub.unacked != nil && !beyondStop {
+		sub.unacked[msg.Sequence] = struct{}{}
+	}
+	sub.Unlock()
 
-	// Perform the callback
-	if cb != nil {
-		cb(msg)
+	// If the message is past the stop position of a bounded subscription,
+	// do not deliver it and close the subscription.
+	if beyondStop {
+		sub.reachedStop()
+		return
+	}
+
+	// Track the message if AckWaitWarning option is set.
+	sub.trackInflight(msg)
+
+	// Perform the callback (or Go channel delivery) through middlewares.
+	if handler != nil {
+		var start time.Time
+		if sub.adaptive != nil {
+			start = time.Now()
+		}
+		if sc.opts.RecoverPanics {
+			sc.invokeAndRecover(handler, msg, isManualAck)
+		} else {
+			handler(msg)
+		}
+		if sub.adaptive != nil {
+			sub.adaptive.record(time.Since(start))
+		}
 	}
 
-	// Process auto-ack
+	// Process auto-ack, unless the message was flagged to not be acked.
 	if !isManualAck {
-		ack := &pb.Ack{Subject: msg.Subject, Sequence: msg.Sequence}
-		b, _ := ack.Marshal()
-		// FIXME(dlc) - Async error handler? Retry?
-		// sc.nc is immutable and never nil once connection is created.
-		sc.nc.Publish(ackSubject, b)
+		if !msg.isNoAck() {
+			ack := &pb.Ack{Subject: msg.Subject, Sequence: msg.Sequence}
+			b, _ := ack.Marshal()
+			// FIXME(dlc) - Async error handler? Retry?
+			// sc.nc is immutable and never nil once connection is created.
+			if sc.nc.Publish(ackSubject, b) == nil {
+				sub.recordAck(msg.Sequence)
+			}
+		}
+		sub.untrackInflight(msg.Sequence)
+	}
+
+	// Close the bounded subscription if this was the last message.
+	if bounded && stopSeq > 0 && msg.Sequence >= stopSeq {
+		sub.reachedStop()
+	}
+}
+
+// invokeAndRecover invokes the handler and, if it panics, notifies the
+// PanicCB handler and applies the PanicPolicy to the message.
+func (sc *conn) invokeAndRecover(handler MsgHandler, msg *Msg, isManualAck bool) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		if cb := sc.opts.PanicCB; cb != nil {
+			cb(msg, r, debug.Stack())
+		}
+		switch sc.opts.PanicPolicy {
+		case PanicNoAck:
+			msg.setNoAck()
+		case PanicAck:
+			if isManualAck {
+				msg.Ack()
+			}
+		case PanicDeadLetter:
+			if err := sc.deadLetter(msg); err != nil {
+				msg.setNoAck()
+			} else if isManualAck {
+				msg.Ack()
+			}
+		}
+	}()
+	handler(msg)
+}
+
+// deadLetter publishes the message's data and header to the dead-letter subject.
+func (sc *conn) deadLetter(msg *Msg) error {
+	// Options are immutable.
+	if sc.opts.DeadLetterSubject == "" {
+		return ErrNoDeadLetter
 	}
+	return sc.PublishMsg(&OutMsg{Subject: sc.opts.DeadLetterSubject, Header: msg.header, Data: msg.Data})
 }
@@ -0,0 +1,182 @@
+// Copyright 2026 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stan
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/nats-io/nats.go"
+)
+
+// Status represents the state of a streaming connection.
+type Status int
+
+const (
+	// Connected means that the NATS connection is established and that
+	// the server responds to the connection's PINGs.
+	Connected Status = iota
+	// Reconnecting means that the underlying NATS connection is
+	// disconnected and trying to reconnect.
+	Reconnecting
+	// PingsMissing means that the NATS connection is established but
+	// that at least one PING sent to the streaming server has not
+	// received a response within the ping interval. The connection is
+	// closed when PingMaxOut PINGs are missing.
+	PingsMissing
+	// Closed means that the connection has been closed, either by the
+	// application or because it was lost.
+	Closed
+)
+
+// String returns a textual representation of the status.
+func (s Status) String() string {
+	switch s {
+	case Connected:
+		return "CONNECTED"
+	case Reconnecting:
+		return "RECONNECTING"
+	case PingsMissing:
+		return "PINGS_MISSING"
+	case Closed:
+		return "CLOSED"
+	}
+	return fmt.Sprintf("UNKNOWN(%d)", int(s))
+}
+
+// StatusChangedHandler is used to be notified of changes of the
+// connection's status.
+type StatusChangedHandler func(old, new Status)
+
+// StatusChanged is an Option to set the handler invoked when the status of
+// the connection changes. The handler is invoked from a separate go routine,
+// in the order in which the changes occurred.
+//
+// Changes of the underlying NATS connection (disconnect and reconnect) are
+// reported only if the library owns the NATS connection, that is, if the
+// NatsConn option is not used. User callbacks provided through NatsOptions
+// are still invoked.
+func StatusChanged(handler StatusChangedHandler) Option {
+	return func(o *Options) error {
+		o.StatusChangedCB = handler
+		return nil
+	}
+}
+
+// Holds the state needed to report status changes.
+type statusInfo struct {
+	mu      sync.Mutex
+	last    Status
+	changes [][2]Status
+	running bool
+}
+
+// Status returns the current status of the connection.
+func (sc *conn) Status() Status {
+	sc.RLock()
+	closed := sc.closed
+	sc.RUnlock()
+	// sc.nc is immutable and never nil once connection is created.
+	if closed || sc.nc.IsClosed() {
+		return Closed
+	}
+	if !sc.nc.IsConnected() {
+		return Reconnecting
+	}
+	p := &sc.ping
+	p.mu.Lock()
+	// One PING is outstanding between the time it is sent and the
+	// time the response is received.
+	missing := p.out > 1
+	p.mu.Unlock()
+	if missing {
+		return PingsMissing
+	}
+	return Connected
+}
+
+// checkStatus computes the current status and, if it has changed,
+// schedules the invocation of the StatusChanged handler.
+// Must not be invoked with the connection or ping lock held.
+func (sc *conn) checkStatus() {
+	// Options are immutable.
+	if sc.opts.StatusChangedCB == nil {
+		return
+	}
+	s := &sc.status
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Closed is a final state.
+	if s.last == Closed {
+		return
+	}
+	cur := sc.Status()
+	if cur == s.last {
+		return
+	}
+	s.add(cur, sc.opts.StatusChangedCB)
+}
+
+// add records the change from the last status to the given one and, if
+// needed, starts a go routine invoking the handler for the pending changes.
+// Status lock is held on entry.
+func (s *statusInfo) add(new Status, handler StatusChangedHandler) {
+	s.changes = append(s.changes, [2]Status{s.last, new})
+	s.last = new
+	if !s.running {
+		s.running = true
+		go s.dispatch(handler)
+	}
+}
+
+// dispatch invokes the handler for each pending change, in order, and
+// returns when there is none left.
+func (s *statusInfo) dispatch(handler StatusChangedHandler) {
+	for {
+		s.mu.Lock()
+		if len(s.changes) == 0 {
+			s.running = false
+			s.mu.Unlock()
+			return
+		}
+		change := s.changes[0]
+		s.changes = s.changes[1:]
+		s.mu.Unlock()
+
+		handler(change[0], change[1])
+	}
+}
+
+// statusNatsOptions returns NATS options that report disconnect and
+// reconnect events while invoking the callbacks found in the given
+// user options.
+func (sc *conn) statusNatsOptions(do *nats.Options) []nats.Option {
+	disconnectedErrCB, disconnectedCB, reconnectedCB := do.DisconnectedErrCB, do.DisconnectedCB, do.ReconnectedCB
+	return []nats.Option{
+		nats.DisconnectErrHandler(func(nc *nats.Conn, err error) {
+			if disconnectedErrCB != nil {
+				disconnectedErrCB(nc, err)
+			} else if disconnectedCB != nil {
+				disconnectedCB(nc)
+			}
+			sc.checkStatus()
+		}),
+		nats.ReconnectHandler(func(nc *nats.Conn) {
+			if reconnectedCB != nil {
+				reconnectedCB(nc)
+			}
+			sc.checkStatus()
+		}),
+	}
+}
@@ -15,13 +15,24 @@ package stan
 
 import (
 	"errors"
+	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/nats-io/nats.go"
 	"github.com/nats-io/stan.go/pb"
 )
 
+const (
+	// redeliverMaxAttempts is the number of times the handler is invoked
+	// for a message while it returns an error wrapped with Redeliver.
+	redeliverMaxAttempts = 5
+	// redeliverMinWait is the initial backoff between such invocations,
+	// doubled after each one.
+	redeliverMinWait = 10 * time.Millisecond
+)
+
 const (
 	// DefaultAckWait indicates how long the server should wait for an ACK before resending a message
 	DefaultAckWait = 30 * time.Second
@@ -33,6 +44,18 @@ const (
 type Msg struct {
 	pb.MsgProto // MsgProto: Seq, Subject, Reply[opt], Data, Timestamp, CRC32[opt]
 	Sub         Subscription
+	noAck       uint32 // Set atomically to prevent the auto-ack of this message.
+	header      nats.Header
+}
+
+// setNoAck prevents the message from being acknowledged in auto-ack mode.
+func (msg *Msg) setNoAck() {
+	atomic.StoreUint32(&msg.noAck, 1)
+}
+
+// isNoAck returns true if the message should not be acknowledged in auto-ack mode.
+func (msg *Msg) isNoAck() bool {
+	return atomic.LoadUint32(&msg.noAck) == 1
 }
 
 // Subscriptions and Options
@@ -88,6 +111,58 @@ type Subscription interface {
 	// SetPendingLimits sets the limits for pending msgs and bytes for the internal low-level NATS Subscription.
 	// Zero is not allowed. Any negative value means that the given metric is not limited.
 	SetPendingLimits(msgLimit, bytesLimit int) error
+
+	// Stats returns client-side statistics about the messages received by this subscription.
+	Stats() SubscriptionStats
+
+	// Subject returns the channel this subscription is on.
+	Subject() string
+
+	// QueueGroup returns the queue group of this subscription, empty if not a queue subscription.
+	QueueGroup() string
+
+	// DurableName returns the durable name of this subscription, empty if not durable.
+	DurableName() string
+
+	// Options returns a copy of the effective options of this subscription.
+	Options() SubscriptionOptions
+
+	// Inbox returns the subject on which the server delivers messages to this subscription.
+	Inbox() string
+
+	// AckInbox returns the subject, assigned by the server, to which acknowledgments are sent.
+	AckInbox() string
+
+	// Pause stops invoking the handler until Resume is called. Messages received
+	// in the meantime are kept in the client, and the server stops sending
+	// messages once MaxInflight messages are unacknowledged. Note that messages
+	// not acknowledged within AckWait are redelivered by the server, so it is
+	// safer to use a long AckWait if pauses are expected to be long.
+	Pause() error
+
+	// Resume resumes invoking the handler after a Pause.
+	Resume() error
+
+	// Update changes the MaxInflight and AckWait of a durable subscription
+	// without losing its position. Returns ErrUpdateNotSupported for other
+	// subscriptions or options.
+	Update(opts ...SubscriptionOption) error
+}
+
+// SubscriptionStats holds client-side statistics of a subscription.
+type SubscriptionStats struct {
+	// Received is the number of messages received, including redeliveries.
+	Received uint64
+	// LastReceived is the time at which the last message was received,
+	// or the zero time if no message has been received yet.
+	LastReceived time.Time
+	// LastSequence is the sequence of the last message received.
+	LastSequence uint64
+	// LastAckedSequence is the sequence of the last message acknowledged.
+	LastAckedSequence uint64
+	// Redelivered is the number of messages received that were flagged
+	// as redelivered by the server.
+	Redelivered uint64
 }
 
 // A subscription represents a subscription to a stan cluster.
@@ -101,12 +176,41 @@ type subscription struct {
 	inboxSub *nats.Subscription
 	opts     SubscriptionOptions
 	cb       MsgHandler
+	ch       chan *Msg     // Go channel for ChanSubscribe, nil otherwise.
+	handler  MsgHandler    // cb or Go channel delivery, wrapped with middlewares. Immutable.
+	closeCh  chan struct{} // Closed on first Close/Unsubscribe.
 	// closed indicate that sub.Close() was invoked, but fullyClosed
 	// is only set if the close/unsub protocol was successful. This
 	// allow the user to be able to call sub.Close() several times
 	// in case an error is returned.
 	closed      bool
 	fullyClosed bool
+	// Timers of messages tracked for the AckWaitWarning option, keyed
+	// by sequence. The map itself is immutable and nil if the option
+	// is not set.
+	inflight map[uint64]*time.Timer
+	// stopped is set when the stop position of a bounded subscription
+	// has been reached.
+	stopped bool
+	// Sequences delivered and not yet acknowledged by a bounded subscription
+	// in manual-ack mode, nil otherwise. Such a subscription is stopped once
+	// stopPending is set and all of them are acknowledged.
+	unacked     map[uint64]struct{}
+	stopPending bool
+	stats   SubscriptionStats
+	// Protects checkpointed, which is the last sequence stored with
+	// the Checkpointer option.
+	cpMu         sync.Mutex
+	checkpointed uint64
+	// resumeCh is not nil while the subscription is paused, and is closed
+	// on Resume.
+	resumeCh chan struct{}
+	// Limits the delivery rate, nil if the option is not set.
+	limiter *rateLimiter
+	// Measures handler latency, nil if AdaptiveMaxInflight is not set.
+	adaptive *adaptiveInflight
+	// Serializes Update with Close and Unsubscribe.
+	updMu sync.Mutex
 }
 
 // SubscriptionOption is a function on the options for a subscription.
@@ -116,6 +220,53 @@ type SubscriptionOption func(*SubscriptionOptions) error
 // asynchronous subscribers.
 type MsgHandler func(msg *Msg)
 
+// MsgHandlerE is a callback function that processes messages delivered to
+// subscribers created with SubscribeE or QueueSubscribeE. Returning nil
+// acknowledges the message, while returning an error leaves it
+// unacknowledged so that it is redelivered after AckWait. The error can
+// be wrapped with DeadLetter or Redeliver to request a different outcome.
+type MsgHandlerE func(msg *Msg) error
+
+// deadLetterError is returned by a MsgHandlerE to request dead-lettering.
+type deadLetterError struct {
+	err error
+}
+
+func (e *deadLetterError) Error() string { return "stan: dead-letter requested: " + e.err.Error() }
+func (e *deadLetterError) Unwrap() error { return e.err }
+
+// redeliverError is returned by a MsgHandlerE to request immediate redelivery.
+type redeliverError struct {
+	err error
+}
+
+func (e *redeliverError) Error() string { return "stan: redelivery requested: " + e.err.Error() }
+func (e *redeliverError) Unwrap() error { return e.err }
+
+// DeadLetter wraps the given error so that, when returned by a MsgHandlerE,
+// the message is published to the subject set with the DeadLetterSubject
+// option and then acknowledged. If the publish fails, the message is not
+// acknowledged.
+func DeadLetter(err error) error {
+	return &deadLetterError{err: err}
+}
+
+// Redeliver wraps the given error so that, when returned by a MsgHandlerE,
+// the handler is invoked again with the same message, after a short backoff,
+// instead of waiting for the cluster to redeliver it after AckWait. The
+// message's Redelivered flag and RedeliveryCount are updated accordingly.
+// The handler is invoked at most 5 times in a row this way, after which the
+// message is left unacknowledged and the cluster redelivers it after AckWait.
+func Redeliver(err error) error {
+	return &redeliverError{err: err}
+}
+
+// AckWaitWarningHandler is a callback function invoked when a message has
+// been held by the application for longer than the threshold specified with
+// the AckWaitWarning option without being acknowledged. The elapsed time
+// since the message was handed to the MsgHandler is passed.
+type AckWaitWarningHandler func(msg *Msg, elapsed time.Duration)
+
 // SubscriptionOptions are used to control the Subscription's behavior.
 type SubscriptionOptions struct {
 	// DurableName, if set will survive client restarts.
@@ -132,6 +283,42 @@ type SubscriptionOptions struct {
 	StartTime time.Time
 	// Option to do Manual Acks
 	ManualAcks bool
+	// Optional duration after which AckWaitWarningCB is invoked for a
+	// message that has not yet been acknowledged. Must be lower than AckWait.
+	AckWaitWarningThreshold time.Duration
+	// Optional callback invoked when a message is not acknowledged within
+	// AckWaitWarningThreshold.
+	AckWaitWarningCB AckWaitWarningHandler
+	// Optional stop sequence. The subscription is closed after the message
+	// with this sequence has been processed.
+	StopSequence uint64
+	// Optional stop time. The subscription is closed when receiving a
+	// message with a timestamp after this time (this message is not
+	// delivered to the MsgHandler).
+	StopTime time.Time
+	// Option to stop at the last sequence in the channel at the time the
+	// subscription is created.
+	StopAtLastReceived bool
+	// Optional channel closed by the library when the subscription has
+	// been closed due to reaching its stop position.
+	StopCh chan<- struct{}
+	// Middlewares applied to the subscription's handler, after the ones
+	// set at the connection level.
+	Middlewares []Middleware
+	// Options applied when the subscription is re-established on another
+	// cluster by a connection created with ConnectWithFailover.
+	FailoverOptions []SubscriptionOption
+	// Optional Checkpointer recording the last processed sequence, from
+	// which the subscription resumes when created.
+	Checkpointer Checkpointer
+	// Name of the consumer under which sequences are checkpointed.
+	CheckpointConsumer string
+	// Optional handler invoked when a sequence could not be checkpointed.
+	CheckpointErrorCB CheckpointErrorHandler
+	// Optional limit of the rate at which messages are delivered to the handler.
+	DeliveryRateLimit RateLimit
+	// Optional configuration to adjust MaxInflight based on handler latency.
+	AdaptiveInflight *AdaptiveInflightConfig
 }
 
 // DefaultSubscriptionOptions are the default subscriptions' options
@@ -217,6 +404,20 @@ func SetManualAckMode() SubscriptionOption {
 	}
 }
 
+// DeliveryRateLimit is an Option to limit the rate at which messages are
+// delivered to the handler. Messages are kept in the client while the limit
+// is exceeded, and the server stops sending messages once MaxInflight
+// messages are unacknowledged.
+func DeliveryRateLimit(limit RateLimit) SubscriptionOption {
+	return func(o *SubscriptionOptions) error {
+		if err := limit.validate(); err != nil {
+			return err
+		}
+		o.DeliveryRateLimit = limit
+		return nil
+	}
+}
+
 // DurableName sets the DurableName for the subscriber.
 func DurableName(name string) SubscriptionOption {
 	return func(o *SubscriptionOptions) error {
@@ -225,6 +426,80 @@ func DurableName(name string) SubscriptionOption {
 	}
 }
 
+// AckWaitWarning is an Option to be notified when a message has not been
+// acknowledged after the given threshold. The cluster does not allow the
+// ack deadline of a delivered message to be extended, so this can be used
+// to detect handlers that are about to exceed AckWait (and cause a
+// redelivery) and to size AckWait accordingly. The threshold must be
+// positive and lower than AckWait. In auto-ack mode, the message is
+// considered acknowledged when the MsgHandler returns. The handler is
+// invoked at most once per delivery, after which the message is no longer
+// tracked.
+func AckWaitWarning(threshold time.Duration, cb AckWaitWarningHandler) SubscriptionOption {
+	return func(o *SubscriptionOptions) error {
+		o.AckWaitWarningThreshold = threshold
+		o.AckWaitWarningCB = cb
+		return nil
+	}
+}
+
+// StopAtSequence sets the sequence at which the subscription is automatically
+// closed. The message with this sequence is delivered, and the subscription
+// is closed after the MsgHandler returns. In manual-ack mode, it is closed
+// once all the messages delivered up to the stop position are acknowledged,
+// so that messages still held by the application (for instance in the Go
+// channel of ChanSubscribe) can be acknowledged. For durables, the
+// subscription is closed, not unsubscribed. Use StopNotify to be notified
+// when this happens.
+func StopAtSequence(seq uint64) SubscriptionOption {
+	return func(o *SubscriptionOptions) error {
+		o.StopSequence = seq
+		return nil
+	}
+}
+
+// StopAtTime sets the time after which the subscription is automatically
+// closed. Messages with a timestamp after this time are not delivered, and
+// the subscription is closed when the first such message is received, or
+// in manual-ack mode once the messages delivered before are acknowledged.
+// If the time is in the past when subscribing, the stop position is resolved
+// against the last message of the channel, so that the subscription is also
+// closed once it has delivered all the messages up to this time even if no
+// message is published afterwards. As with StopAtLastReceived, this requires
+// waiting for the connection's ConnectWait timeout if the channel is empty.
+// Use StopNotify to be notified when this happens.
+func StopAtTime(stop time.Time) SubscriptionOption {
+	return func(o *SubscriptionOptions) error {
+		o.StopTime = stop
+		return nil
+	}
+}
+
+// StopAtLastReceived sets the stop sequence to the sequence of the last
+// message in the channel at the time the subscription is created, which
+// means that messages published after that are not delivered. If the
+// channel is empty, or if the start position is after the last message
+// (for instance, with the default start position of a non-durable
+// subscription), the subscription is closed right away.
+// Note that finding the last sequence of an empty channel requires waiting
+// for the connection's ConnectWait timeout.
+func StopAtLastReceived() SubscriptionOption {
+	return func(o *SubscriptionOptions) error {
+		o.StopAtLastReceived = true
+		return nil
+	}
+}
+
+// StopNotify sets a channel that the library closes when a subscription
+// created with StopAtSequence, StopAtTime or StopAtLastReceived has
+// reached its stop position and has been closed.
+func StopNotify(ch chan<- struct{}) SubscriptionOption {
+	return func(o *SubscriptionOptions) error {
+		o.StopCh = ch
+		return nil
+	}
+}
+
 // Subscribe will perform a subscription with the given options to the NATS Streaming cluster.
 func (sc *conn) Subscribe(subject string, cb MsgHandler, options ...SubscriptionOption) (Subscription, error) {
 	return sc.subscribe(subject, "", cb, options...)
@@ -235,14 +510,143 @@ func (sc *conn) QueueSubscribe(subject, qgroup string, cb MsgHandler, options ..
 	return sc.subscribe(subject, qgroup, cb, options...)
 }
 
+// SubscribeE will perform a subscription with the given options to the NATS Streaming cluster,
+// with messages being acknowledged based on the error returned by the handler.
+func (sc *conn) SubscribeE(subject string, cb MsgHandlerE, options ...SubscriptionOption) (Subscription, error) {
+	return sc.subscribeE(subject, "", cb, options...)
+}
+
+// QueueSubscribeE will perform a queue subscription with the given options to the NATS Streaming cluster,
+// with messages being acknowledged based on the error returned by the handler.
+func (sc *conn) QueueSubscribeE(subject, qgroup string, cb MsgHandlerE, options ...SubscriptionOption) (Subscription, error) {
+	return sc.subscribeE(subject, qgroup, cb, options...)
+}
+
+// subscribeE will perform a subscription in manual-ack mode, acknowledging messages
+// based on the error returned by the handler.
+func (sc *conn) subscribeE(subject, qgroup string, cb MsgHandlerE, options ...SubscriptionOption) (Subscription, error) {
+	if cb == nil {
+		return nil, ErrNilHandler
+	}
+	options = append(options, SetManualAckMode())
+	return sc.subscribe(subject, qgroup, func(msg *Msg) {
+		sc.processHandlerError(msg, cb)
+	}, options...)
+}
+
+// processHandlerError invokes the handler and acknowledges, dead-letters or
+// redelivers the message based on the returned error.
+func (sc *conn) processHandlerError(msg *Msg, cb MsgHandlerE) {
+	sub := msg.Sub.(*subscription)
+	wait := redeliverMinWait
+	for attempt := 1; ; attempt++ {
+		err := cb(msg)
+		if err == nil {
+			msg.Ack()
+			return
+		}
+		var dle *deadLetterError
+		if errors.As(err, &dle) {
+			if sc.deadLetter(msg) == nil {
+				msg.Ack()
+			}
+			return
+		}
+		var rde *redeliverError
+		if !errors.As(err, &rde) || attempt >= redeliverMaxAttempts {
+			// Let the cluster redeliver after AckWait.
+			return
+		}
+		select {
+		case <-time.After(wait):
+		case <-sub.closeCh:
+			return
+		case <-sc.pubAckCloseChan:
+			return
+		}
+		wait *= 2
+		msg.Redelivered = true
+		msg.RedeliveryCount++
+	}
+}
+
+// ChanSubscribe will perform a subscription with the given options to the NATS Streaming cluster
+// and deliver messages to the given Go channel.
+func (sc *conn) ChanSubscribe(subject string, ch chan *Msg, options ...SubscriptionOption) (Subscription, error) {
+	return sc.chanSubscribe(subject, "", ch, options...)
+}
+
+// ChanQueueSubscribe will perform a queue subscription with the given options to the NATS Streaming cluster
+// and deliver messages to the given Go channel.
+func (sc *conn) ChanQueueSubscribe(subject, qgroup string, ch chan *Msg, options ...SubscriptionOption) (Subscription, error) {
+	return sc.chanSubscribe(subject, qgroup, ch, options...)
+}
+
+// chanSubscribe will perform a subscription delivering messages to the given Go channel.
+func (sc *conn) chanSubscribe(subject, qgroup string, ch chan *Msg, options ...SubscriptionOption) (Subscription, error) {
+	if ch == nil {
+		return nil, ErrNilChan
+	}
+	return sc.subscribeWithChan(subject, qgroup, nil, ch, options...)
+}
+
 // subscribe will perform a subscription with the given options to the NATS Streaming cluster.
 func (sc *conn) subscribe(subject, qgroup string, cb MsgHandler, options ...SubscriptionOption) (Subscription, error) {
-	sub := &subscription{subject: subject, qgroup: qgroup, inbox: nats.NewInbox(), cb: cb, sc: sc, opts: DefaultSubscriptionOptions}
+	return sc.subscribeWithChan(subject, qgroup, cb, nil, options...)
+}
+
+// subscribeWithChan will perform a subscription with the given options to the NATS Streaming cluster.
+// Messages are either passed to the callback or, if not nil, sent to the Go channel.
+func (sc *conn) subscribeWithChan(subject, qgroup string, cb MsgHandler, ch chan *Msg, options ...SubscriptionOption) (Subscription, error) {
+	sub := &subscription{subject: subject, qgroup: qgroup, inbox: nats.NewInbox(), cb: cb, ch: ch, closeCh: make(chan struct{}), sc: sc, opts: DefaultSubscriptionOptions}
 	for _, opt := range options {
 		if err := opt(&sub.opts); err != nil {
 			return nil, err
 		}
 	}
+	if sub.opts.AckWaitWarningCB != nil {
+		if t := sub.opts.AckWaitWarningThreshold; t <= 0 || t >= sub.opts.AckWait {
+			return nil, fmt.Errorf("invalid ack wait warning threshold: %v (min>0, max<%v)", t, sub.opts.AckWait)
+		}
+		sub.inflight = make(map[uint64]*time.Timer)
+	}
+	sub.handler = cb
+	if ch != nil {
+		sub.handler = sub.deliverToChan
+	}
+	if len(sc.opts.Middlewares) > 0 || len(sub.opts.Middlewares) > 0 {
+		if sub.handler == nil {
+			sub.handler = func(*Msg) {}
+		}
+		sub.handler = chainMiddlewares(sub.handler, sc.opts.Middlewares, sub.opts.Middlewares)
+	}
+	if sub.opts.AdaptiveInflight != nil {
+		if sub.opts.DurableName == "" {
+			return nil, ErrUpdateNotSupported
+		}
+		sub.adaptive = &adaptiveInflight{}
+	}
+	if sub.opts.DeliveryRateLimit.isSet() {
+		sub.limiter = newRateLimiter(sub.opts.DeliveryRateLimit)
+	}
+	if sub.opts.Checkpointer != nil {
+		if err := sub.resumeFromCheckpoint(); err != nil {
+			return nil, err
+		}
+	}
+	// Resolve a stop position that is already in the channel, so that the
+	// subscription is closed even if no message is published after it.
+	stopNow := false
+	if sub.opts.StopAtLastReceived || (!sub.opts.StopTime.IsZero() && !sub.opts.StopTime.After(time.Now())) {
+		last, err := sc.lastMsg(subject)
+		if err != nil {
+			return nil, err
+		}
+		stopNow = sub.resolveStop(last)
+	}
+	if sub.opts.ManualAcks && sub.isBounded() {
+		sub.unacked = make(map[uint64]struct{})
+	}
 	sc.Lock()
 	if sc.closed {
 		sc.Unlock()
@@ -338,9 +742,188 @@ func (sc *conn) subscribe(subject, qgroup string, cb MsgHandler, options ...Subs
 	// Prevent cleanup on exit.
 	doClean = false
 
+	if sub.adaptive != nil {
+		interval := sub.opts.AdaptiveInflight.Interval
+		if interval == 0 {
+			interval = sub.opts.AckWait
+		}
+		go sub.runAdaptiveInflight(interval)
+	}
+
+	if stopNow {
+		go sub.stop()
+	}
+
 	return sub, nil
 }
 
+// deliverToChan sends the message to the subscription's Go channel. If the
+// subscription or connection is closed while waiting for room in the
+// channel, the message is not acknowledged.
+func (sub *subscription) deliverToChan(msg *Msg) {
+	select {
+	case sub.ch <- msg:
+	case <-sub.closeCh:
+		msg.setNoAck()
+	case <-sub.sc.pubAckCloseChan:
+		msg.setNoAck()
+	}
+}
+
+// lastMsg returns the last message in the given channel, or nil if the
+// channel is empty. It uses an internal subscription that is not registered
+// with the connection, so that the message is not seen by the middlewares,
+// signature verification or schema validation.
+func (sc *conn) lastMsg(subject string) (*Msg, error) {
+	// sc.nc is immutable and never nil once connection is created.
+	inbox := nats.NewInbox()
+	nsub, err := sc.nc.SubscribeSync(inbox)
+	if err != nil {
+		return nil, err
+	}
+	defer nsub.Unsubscribe()
+
+	sr := &pb.SubscriptionRequest{
+		ClientID:      sc.clientID,
+		Subject:       subject,
+		Inbox:         inbox,
+		MaxInFlight:   1,
+		AckWaitInSecs: int32(DefaultAckWait / time.Second),
+		StartPosition: pb.StartPosition_LastReceived,
+	}
+	b, _ := sr.Marshal()
+	reply, err := sc.nc.Request(sc.subRequests, b, sc.opts.ConnectTimeout)
+	if err != nil {
+		if err == nats.ErrTimeout || err == nats.ErrNoResponders {
+			err = ErrSubReqTimeout
+		}
+		return nil, err
+	}
+	r := &pb.SubscriptionResponse{}
+	if err := r.Unmarshal(reply.Data); err != nil {
+		return nil, err
+	}
+	if r.Error != "" {
+		return nil, errors.New(r.Error)
+	}
+	defer func() {
+		usr := &pb.UnsubscribeRequest{ClientID: sc.clientID, Subject: subject, Inbox: r.AckInbox}
+		b, _ := usr.Marshal()
+		sc.nc.Publish(sc.unsubRequests, b)
+	}()
+
+	raw, err := nsub.NextMsg(sc.opts.ConnectTimeout)
+	if err == nats.ErrTimeout {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	msg := &Msg{}
+	if err := msg.Unmarshal(raw.Data); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// resolveStop sets the stop sequence of a subscription bounded to the last
+// message of the channel, or to a time that has passed, given the last
+// message (nil if the channel is empty). Returns true if there is nothing
+// to deliver, so that the subscription must be closed right away.
+func (sub *subscription) resolveStop(last *Msg) bool {
+	if last == nil {
+		return true
+	}
+	o := &sub.opts
+	// If the last message is past the stop time, the subscription is closed
+	// when it reaches the first such message.
+	if o.StopAtLastReceived || last.Timestamp <= o.StopTime.UnixNano() {
+		if o.StopSequence == 0 || last.Sequence < o.StopSequence {
+			o.StopSequence = last.Sequence
+		}
+	}
+	// The stop position is at or before the last message, so nothing will
+	// be delivered if the subscription starts after it. The position of a
+	// durable that already exists is not known.
+	if o.DurableName != "" {
+		return false
+	}
+	switch o.StartAt {
+	case pb.StartPosition_NewOnly:
+		return true
+	case pb.StartPosition_SequenceStart:
+		return o.StartSequence > last.Sequence
+	case pb.StartPosition_TimeDeltaStart:
+		return o.StartTime.UnixNano() > last.Timestamp
+	}
+	return false
+}
+
+// isBounded returns true if the subscription has a stop position.
+// Subscription lock is held on entry.
+func (sub *subscription) isBounded() bool {
+	return sub.opts.StopSequence > 0 || !sub.opts.StopTime.IsZero()
+}
+
+// beyondStop returns true if the given message is past the stop position
+// of the subscription. Subscription lock is held on entry.
+func (sub *subscription) beyondStop(msg *Msg) bool {
+	o := &sub.opts
+	return (o.StopSequence > 0 && msg.Sequence > o.StopSequence) ||
+		(!o.StopTime.IsZero() && msg.Timestamp > o.StopTime.UnixNano())
+}
+
+// reachedStop is invoked when a bounded subscription reaches its stop
+// position. In manual-ack mode, the subscription is stopped once the
+// messages that were delivered are acknowledged, otherwise right away.
+func (sub *subscription) reachedStop() {
+	sub.Lock()
+	sub.stopPending = true
+	wait := len(sub.unacked) > 0
+	sub.Unlock()
+	if !wait {
+		sub.stop()
+	}
+}
+
+// recordBoundedAck stops a bounded subscription in manual-ack mode that
+// has reached its stop position once all delivered messages are acked.
+func (sub *subscription) recordBoundedAck(seq uint64) {
+	if sub.unacked == nil {
+		return
+	}
+	sub.Lock()
+	delete(sub.unacked, seq)
+	done := sub.stopPending && len(sub.unacked) == 0
+	sub.Unlock()
+	if done {
+		sub.stop()
+	}
+}
+
+// stop closes (for durables) or unsubscribes a bounded subscription that
+// has reached its stop position and notifies the user.
+func (sub *subscription) stop() {
+	sub.Lock()
+	if sub.stopped {
+		sub.Unlock()
+		return
+	}
+	sub.stopped = true
+	durable := sub.opts.DurableName != ""
+	ch := sub.opts.StopCh
+	sub.Unlock()
+
+	if durable {
+		sub.Close()
+	} else {
+		sub.Unsubscribe()
+	}
+	if ch != nil {
+		close(ch)
+	}
+}
+
 // ClearMaxPending resets the maximums seen so far.
 func (sub *subscription) ClearMaxPending() error {
 	sub.Lock()
@@ -429,9 +1012,147 @@ func (sub *subscription) SetPendingLimits(msgLimit, bytesLimit int) error {
 	return sub.inboxSub.SetPendingLimits(msgLimit, bytesLimit)
 }
 
+// Stats returns client-side statistics about the messages received by this subscription.
+func (sub *subscription) Stats() SubscriptionStats {
+	sub.RLock()
+	defer sub.RUnlock()
+	return sub.stats
+}
+
+// Subject returns the channel this subscription is on.
+func (sub *subscription) Subject() string {
+	// subject is immutable.
+	return sub.subject
+}
+
+// QueueGroup returns the queue group of this subscription, empty if not a queue subscription.
+func (sub *subscription) QueueGroup() string {
+	// qgroup is immutable.
+	return sub.qgroup
+}
+
+// DurableName returns the durable name of this subscription, empty if not durable.
+func (sub *subscription) DurableName() string {
+	sub.RLock()
+	defer sub.RUnlock()
+	return sub.opts.DurableName
+}
+
+// Options returns a copy of the effective options of this subscription.
+func (sub *subscription) Options() SubscriptionOptions {
+	sub.RLock()
+	defer sub.RUnlock()
+	return sub.opts
+}
+
+// Inbox returns the subject on which the server delivers messages to this subscription.
+func (sub *subscription) Inbox() string {
+	sub.RLock()
+	defer sub.RUnlock()
+	return sub.inbox
+}
+
+// AckInbox returns the subject, assigned by the server, to which acknowledgments are sent.
+func (sub *subscription) AckInbox() string {
+	sub.RLock()
+	defer sub.RUnlock()
+	return sub.ackInbox
+}
+
+// Pause stops invoking the handler until Resume is called.
+func (sub *subscription) Pause() error {
+	sub.Lock()
+	defer sub.Unlock()
+	if sub.closed {
+		return ErrBadSubscription
+	}
+	if sub.resumeCh == nil {
+		sub.resumeCh = make(chan struct{})
+	}
+	return nil
+}
+
+// Resume resumes invoking the handler after a Pause.
+func (sub *subscription) Resume() error {
+	sub.Lock()
+	defer sub.Unlock()
+	if sub.closed {
+		return ErrBadSubscription
+	}
+	if sub.resumeCh != nil {
+		close(sub.resumeCh)
+		sub.resumeCh = nil
+	}
+	return nil
+}
+
+// waitForDelivery blocks while the subscription is paused or its delivery
+// rate limit is exceeded for a message of the given size. Returns false if
+// the subscription or the connection is closed in the meantime.
+func (sub *subscription) waitForDelivery(size int) bool {
+	for {
+		sub.Lock()
+		if sub.closed {
+			sub.Unlock()
+			return false
+		}
+		resumeCh := sub.resumeCh
+		var d time.Duration
+		if resumeCh == nil && sub.limiter != nil {
+			if d = sub.limiter.wait(time.Now(), size); d == 0 {
+				sub.limiter.take(size)
+			}
+		}
+		sub.Unlock()
+
+		if resumeCh == nil && d == 0 {
+			return true
+		}
+		// Wait for a resume, or for the limit. In both cases, check again.
+		var t *time.Timer
+		var timeout <-chan time.Time
+		if resumeCh == nil {
+			t = time.NewTimer(d)
+			timeout = t.C
+		}
+		select {
+		case <-resumeCh:
+		case <-timeout:
+		case <-sub.closeCh:
+			if t != nil {
+				t.Stop()
+			}
+			return false
+		case <-sub.sc.pubAckCloseChan:
+			// The connection has been closed.
+			if t != nil {
+				t.Stop()
+			}
+			return false
+		}
+		if t != nil {
+			t.Stop()
+		}
+	}
+}
+
+// recordAck updates the statistics after the message with the given
+// sequence has been acknowledged.
+func (sub *subscription) recordAck(seq uint64) {
+	sub.Lock()
+	sub.stats.LastAckedSequence = seq
+	sub.Unlock()
+	// Options are immutable once the subscription is created.
+	if sub.opts.Checkpointer != nil {
+		sub.checkpoint(seq)
+	}
+}
+
 // closeOrUnsubscribe performs either close or unsubsribe based on
 // given boolean.
 func (sub *subscription) closeOrUnsubscribe(doClose bool) error {
+	sub.updMu.Lock()
+	defer sub.updMu.Unlock()
 	sub.Lock()
 	// If we are fully closed, return error indicating that the
 	// subscription is invalid. Note that conn.Close() in this case
@@ -445,8 +1166,13 @@ func (sub *subscription) closeOrUnsubscribe(doClose bool) error {
 	// otherwise, simply send the close protocol message.
 	if !wasClosed {
 		sub.closed = true
+		close(sub.closeCh)
 		sub.inboxSub.Unsubscribe()
 		sub.inboxSub = nil
+		for seq, t := range sub.inflight {
+			t.Stop()
+			delete(sub.inflight, seq)
+		}
 	}
 	sc := sub.sc
 	sub.Unlock()
@@ -543,5 +1269,59 @@ func (msg *Msg) Ack() error {
 	if err == nats.ErrConnectionClosed {
 		return ErrBadConnection
 	}
+	if err == nil {
+		sub.recordAck(msg.Sequence)
+		sub.untrackInflight(msg.Sequence)
+		sub.recordBoundedAck(msg.Sequence)
+	}
 	return err
 }
+
+// trackInflight starts a timer that will invoke the AckWaitWarning
+// callback if the message is not acknowledged in time.
+// This is a no-op if the subscription was not created with this option.
+func (sub *subscription) trackInflight(msg *Msg) {
+	if sub.inflight == nil {
+		return
+	}
+	sub.Lock()
+	defer sub.Unlock()
+	if sub.closed {
+		return
+	}
+	// A redelivered message replaces the previous tracking.
+	if t := sub.inflight[msg.Sequence]; t != nil {
+		t.Stop()
+	}
+	start := time.Now()
+	cb := sub.opts.AckWaitWarningCB
+	var t *time.Timer
+	t = time.AfterFunc(sub.opts.AckWaitWarningThreshold, func() {
+		sub.Lock()
+		// Check that we are still tracking this message. The warning fires
+		// once, so stop tracking it: a message that is never acknowledged,
+		// for instance redelivered to another queue member, must not leak.
+		active := sub.inflight[msg.Sequence] == t
+		if active {
+			delete(sub.inflight, msg.Sequence)
+		}
+		sub.Unlock()
+		if active {
+			cb(msg, time.Since(start))
+		}
+	})
+	sub.inflight[msg.Sequence] = t
+}
+
+// untrackInflight stops tracking the message with the given sequence.
+func (sub *subscription) untrackInflight(seq uint64) {
+	if sub.inflight == nil {
+		return
+	}
+	sub.Lock()
+	if t := sub.inflight[seq]; t != nil {
+		t.Stop()
+		delete(sub.inflight, seq)
+	}
+	sub.Unlock()
+}
@@ -0,0 +1,194 @@
+// Copyright 2026 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stan
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/stan.go/pb"
+)
+
+// ErrUpdateNotSupported is returned when updating a subscription that is
+// not durable, or with options that cannot be updated.
+var ErrUpdateNotSupported = errors.New("stan: update only supported for MaxInflight and AckWait of durable subscriptions")
+
+// Update changes the MaxInflight and AckWait of a durable subscription by
+// closing it on the server and re-creating it with the new values on a new
+// inbox. The server keeps the position of the durable, and messages that
+// were not acknowledged before the update are redelivered. Only the
+// MaxInflight and AckWait options are accepted.
+//
+// If re-creating the subscription fails, the subscription is closed and
+// the error is returned. The durable interest is however maintained, so
+// the subscription can be re-created with the same durable name.
+func (sub *subscription) Update(options ...SubscriptionOption) error {
+	var upd SubscriptionOptions
+	for _, opt := range options {
+		if err := opt(&upd); err != nil {
+			return err
+		}
+	}
+	maxInflight, ackWait := upd.MaxInflight, upd.AckWait
+	upd.MaxInflight, upd.AckWait = 0, 0
+	if !reflect.DeepEqual(upd, SubscriptionOptions{}) {
+		return ErrUpdateNotSupported
+	}
+
+	// Serialize with other updates and with Close and Unsubscribe. The
+	// subscription lock is not held during the requests to the server, so
+	// that messages keep being processed and acknowledged meanwhile.
+	sub.updMu.Lock()
+	defer sub.updMu.Unlock()
+	sub.RLock()
+	closed, cur, ackInbox := sub.closed, sub.opts, sub.ackInbox
+	sub.RUnlock()
+	if closed {
+		return ErrBadSubscription
+	}
+	if cur.DurableName == "" {
+		return ErrUpdateNotSupported
+	}
+	opts := cur
+	if maxInflight > 0 {
+		opts.MaxInflight = maxInflight
+	}
+	if ackWait > 0 {
+		opts.AckWait = ackWait
+	}
+	if opts.MaxInflight == cur.MaxInflight && opts.AckWait == cur.AckWait {
+		return nil
+	}
+	if opts.AckWaitWarningCB != nil && opts.AckWaitWarningThreshold >= opts.AckWait {
+		return fmt.Errorf("invalid ack wait warning threshold: %v (max<%v)", opts.AckWaitWarningThreshold, opts.AckWait)
+	}
+
+	sc := sub.sc
+	sc.RLock()
+	closed, subCloseRequests := sc.closed, sc.subCloseRequests
+	sc.RUnlock()
+	if closed {
+		return ErrConnectionClosed
+	}
+	if subCloseRequests == "" {
+		return ErrNoServerSupport
+	}
+
+	// Close the subscription on the server.
+	// sc.nc is immutable and never nil once connection is created.
+	usr := &pb.UnsubscribeRequest{ClientID: sc.clientID, Subject: sub.subject, Inbox: ackInbox}
+	b, _ := usr.Marshal()
+	reply, err := sc.nc.Request(subCloseRequests, b, sc.opts.ConnectTimeout)
+	if err == nil {
+		r := &pb.SubscriptionResponse{}
+		if err = r.Unmarshal(reply.Data); err == nil && r.Error != "" {
+			err = errors.New(r.Error)
+		}
+	}
+	if err != nil {
+		if err == nats.ErrTimeout || err == nats.ErrNoResponders {
+			err = ErrCloseReqTimeout
+		}
+		return err
+	}
+
+	// Re-create it on a new inbox. Messages that were not acknowledged are
+	// redelivered there, and are held until the subscription is updated so
+	// that they are acknowledged on the new ack inbox.
+	inbox := nats.NewInbox()
+	ready := make(chan struct{})
+	defer close(ready)
+	nsub, newAckInbox, err := sub.resubscribe(inbox, ready, opts)
+
+	sub.Lock()
+	oldSub, oldInbox := sub.inboxSub, sub.inbox
+	if err == nil {
+		sub.inbox, sub.inboxSub, sub.ackInbox = inbox, nsub, newAckInbox
+		sub.opts.MaxInflight, sub.opts.AckWait = opts.MaxInflight, opts.AckWait
+	} else {
+		sub.closed = true
+		sub.fullyClosed = true
+		close(sub.closeCh)
+		sub.inboxSub = nil
+		for seq, t := range sub.inflight {
+			t.Stop()
+			delete(sub.inflight, seq)
+		}
+	}
+	sub.Unlock()
+
+	// Stop receiving on the old inbox.
+	oldSub.Unsubscribe()
+	sc.Lock()
+	delete(sc.subMap, oldInbox)
+	if err == nil {
+		sc.subMap[inbox] = sub
+	}
+	sc.Unlock()
+	if err != nil {
+		return fmt.Errorf("stan: subscription closed after failed update: %v", err)
+	}
+	return nil
+}
+
+// resubscribe re-creates the durable subscription on the server with the
+// given options, receiving on the given inbox. Messages are processed once
+// ready is closed. It returns the NATS subscription and the ack inbox.
+func (sub *subscription) resubscribe(inbox string, ready chan struct{}, opts SubscriptionOptions) (*nats.Subscription, string, error) {
+	sc := sub.sc
+	nsub, err := sc.nc.Subscribe(inbox, func(m *nats.Msg) {
+		<-ready
+		sc.processMsg(m)
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	nsub.SetPendingLimits(-1, -1)
+
+	sr := &pb.SubscriptionRequest{
+		ClientID:      sc.clientID,
+		Subject:       sub.subject,
+		QGroup:        sub.qgroup,
+		Inbox:         inbox,
+		MaxInFlight:   int32(opts.MaxInflight),
+		AckWaitInSecs: int32(opts.AckWait / time.Second),
+		DurableName:   opts.DurableName,
+		// The server resumes the durable, so this is ignored.
+		StartPosition: pb.StartPosition_NewOnly,
+	}
+	b, _ := sr.Marshal()
+	var ackInbox string
+	reply, err := sc.nc.Request(sc.subRequests, b, sc.opts.ConnectTimeout)
+	if err == nil {
+		r := &pb.SubscriptionResponse{}
+		if err = r.Unmarshal(reply.Data); err == nil {
+			if r.Error != "" {
+				err = errors.New(r.Error)
+			} else {
+				ackInbox = r.AckInbox
+			}
+		}
+	}
+	if err != nil {
+		nsub.Unsubscribe()
+		if err == nats.ErrTimeout || err == nats.ErrNoResponders {
+			err = ErrSubReqTimeout
+		}
+		return nil, "", err
+	}
+	return nsub, ackInbox, nil
+}